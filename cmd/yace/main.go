@@ -3,8 +3,11 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -17,23 +20,140 @@ import (
 var version = "custom-build"
 
 var (
-	addr                  = flag.String("listen-address", ":5000", "The address to listen on.")
-	configFile            = flag.String("config.file", "config.yml", "Path to configuration file.")
-	debug                 = flag.Bool("debug", false, "Add verbose logging.")
-	fips                  = flag.Bool("fips", false, "Use FIPS compliant aws api.")
-	showVersion           = flag.Bool("v", false, "prints current yace version.")
-	cloudwatchConcurrency = flag.Int("cloudwatch-concurrency", 5, "Maximum number of concurrent requests to CloudWatch API.")
-	tagConcurrency        = flag.Int("tag-concurrency", 5, "Maximum number of concurrent requests to Resource Tagging API.")
-	scrapingInterval      = flag.Int("scraping-interval", 300, "Seconds to wait between scraping the AWS metrics if decoupled scraping.")
-	decoupledScraping     = flag.Bool("decoupled-scraping", true, "Decouples scraping and serving of metrics.")
-	metricsPerQuery       = flag.Int("metrics-per-query", 500, "Number of metrics made in a single GetMetricsData request")
-	labelsSnakeCase       = flag.Bool("labels-snake-case", false, "If labels should be output in snake case instead of camel case")
-	floatingTimeWindow    = flag.Bool("floating-time-window", false, "Use a floating start/end time window instead of rounding times to 5 min intervals")
-	verifyConfig          = flag.Bool("verify-config", false, "Loads and attempts to parse config file, then exits. Useful for CICD validation")
-
-	config = exporter.ScrapeConf{}
+	addr                    = flag.String("listen-address", ":5000", "The address to listen on.")
+	configFile              = flag.String("config.file", "config.yml", "Path to configuration file, s3://bucket/key, ssm://parameter-name or an http(s) URL.")
+	configAuthorizationFile = flag.String("config.authorization-file", "", "File holding the Authorization header of requests for the configuration file over http(s).")
+	configUserAgent         = flag.String("config.user-agent", "", "Appended to the User-Agent of requests for the configuration file in S3 or SSM.")
+	configRefreshInterval   = flag.Int("config.refresh-interval", 0, "Seconds between reloads of the configuration file. Disabled with 0.")
+	debug                   = flag.Bool("debug", false, "Add verbose logging.")
+	fips                    = flag.Bool("fips", false, "Use FIPS compliant aws api.")
+	showVersion             = flag.Bool("v", false, "prints current yace version.")
+	cloudwatchConcurrency   = flag.Int("cloudwatch-concurrency", 5, "Maximum number of concurrent requests to CloudWatch API.")
+	tagConcurrency          = flag.Int("tag-concurrency", 5, "Maximum number of concurrent requests to Resource Tagging API.")
+	scrapingInterval        = flag.Int("scraping-interval", 300, "Seconds to wait between scraping the AWS metrics if decoupled scraping.")
+	decoupledScraping       = flag.Bool("decoupled-scraping", true, "Decouples scraping and serving of metrics.")
+	metricsPerQuery         = flag.Int("metrics-per-query", 500, "Number of metrics made in a single GetMetricsData request")
+	labelsSnakeCase         = flag.Bool("labels-snake-case", false, "If labels should be output in snake case instead of camel case")
+	floatingTimeWindow      = flag.Bool("floating-time-window", false, "Use a floating start/end time window instead of rounding times to 5 min intervals")
+	verifyConfig            = flag.Bool("verify-config", false, "Loads and attempts to parse config file, then exits. Useful for CICD validation")
+	migrateConfig           = flag.Bool("migrate-config", false, "Prints the config file upgraded to the current apiVersion, then exits")
+	cloudwatchQuotaFraction = flag.Float64("cloudwatch-quota-fraction", 0, "Limit CloudWatch requests to this fraction of the account's Service Quotas rate quotas, e.g. 0.5. Disabled with 0.")
+	streamingMetrics        = flag.Bool("streaming-metrics", false, "Stream the /metrics response in the text format instead of building it in memory first")
+	enableRediscover        = flag.Bool("enable-rediscover", false, "Serve POST /-/rediscover to query suppressed series again and scrape early with decoupled scraping")
+	influxLineProtocol      = flag.Bool("influx-line-protocol", false, "Also serve the metrics in InfluxDB line protocol at /influx")
+
+	config    = exporter.ScrapeConf{}
+	configMux sync.RWMutex
+
+	// rediscover wakes the decoupled scraping loop early, a pending wake-up is enough for any number of requests
+	rediscover = make(chan struct{}, 1)
+
+	// lastScrapeStart is when the decoupled scraping loop started its last scrape
+	lastScrapeStart    time.Time
+	lastScrapeStartMux sync.Mutex
 )
 
+// currentConfig returns the last successfully loaded config
+func currentConfig() exporter.ScrapeConf {
+	configMux.RLock()
+	defer configMux.RUnlock()
+	return config
+}
+
+// currentScrapingInterval returns the seconds between scrapes with the current config. To avoid querying for future
+// timestamps the interval is at least the length of the longest discovery job, it's recomputed for every scrape so it
+// follows reloaded configs.
+func currentScrapingInterval() int {
+	interval := *scrapingInterval
+	for _, discoveryJob := range currentConfig().Discovery.Jobs {
+		// S3 can have up to 1 day, it ignores the length
+		svc := exporter.SupportedServices.GetService(discoveryJob.Type)
+		if length := exporter.GetMetricDataInputLength(discoveryJob); length > interval && !svc.IgnoreLength {
+			interval = length
+		}
+	}
+	return interval
+}
+
+// refreshConfig reloads the config in intervals, a config failing to load or validate keeps the current config
+func refreshConfig(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		newConfig := exporter.ScrapeConf{}
+		if err := newConfig.Load(configFile); err != nil {
+			log.Error("Couldn't reload ", *configFile, ", keeping the current config: ", err)
+			continue
+		}
+		configMux.Lock()
+		if !reflect.DeepEqual(config, newConfig) {
+			log.Info("Config ", *configFile, " changed, reloaded")
+			exporter.ResetUnretainedPeriodWarnings()
+		}
+		config = newConfig
+		configMux.Unlock()
+	}
+}
+
+// sleepOrRediscover waits for the scraping interval or until a rediscovery is requested
+func sleepOrRediscover(d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-rediscover:
+		log.Info("Rediscovery requested, scraping again")
+	}
+}
+
+func setLastScrapeStart(t time.Time) {
+	lastScrapeStartMux.Lock()
+	defer lastScrapeStartMux.Unlock()
+	lastScrapeStart = t
+}
+
+// canScrapeEarly returns whether a rediscovery may start a scrape before the scraping interval. Scrapes are started at
+// most every half scraping interval, so requests in a loop can't cause back-to-back scrapes of all jobs.
+func canScrapeEarly(now time.Time) bool {
+	lastScrapeStartMux.Lock()
+	defer lastScrapeStartMux.Unlock()
+	return now.Sub(lastScrapeStart) >= time.Duration(currentScrapingInterval())*time.Second/2
+}
+
+// rediscoverHandler makes the next scrape query all series of a discovery job type again, or of all jobs without a
+// job parameter. With decoupled scraping the next scrape starts immediately, unless the last one started less than half
+// a scraping interval ago.
+func rediscoverHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	jobType := r.URL.Query().Get("job")
+	if jobType != "" && !hasDiscoveryJob(currentConfig(), jobType) {
+		http.Error(w, fmt.Sprintf("No discovery job of type %s", jobType), http.StatusNotFound)
+		return
+	}
+	exporter.Rediscover(jobType)
+	if !canScrapeEarly(time.Now()) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("Rediscovering with the next scrape, the last scrape started less than half a scraping interval ago\n"))
+		return
+	}
+	select {
+	case rediscover <- struct{}{}:
+	default:
+	}
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write([]byte("Rediscovering now\n"))
+}
+
+func hasDiscoveryJob(config exporter.ScrapeConf, jobType string) bool {
+	for _, job := range config.Discovery.Jobs {
+		if job.Type == jobType {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
 
 	// Set JSON structured logging as the default log formatter
@@ -59,56 +179,71 @@ func main() {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	if *migrateConfig {
+		// The migrated config is printed to stdout
+		log.SetOutput(os.Stderr)
+		yamlFile, err := ioutil.ReadFile(*configFile)
+		if err != nil {
+			log.Fatal("Couldn't read ", *configFile, ": ", err)
+		}
+		migrated, err := exporter.MigrateConfig(yamlFile)
+		if err != nil {
+			log.Fatal("Couldn't migrate ", *configFile, ": ", err)
+		}
+		fmt.Print(string(migrated))
+		os.Exit(0)
+	}
+
+	exporter.SetConfigAuthorizationFile(*configFile, *configAuthorizationFile)
+	exporter.SetConfigUserAgent(*configUserAgent)
+
 	log.Println("Parse config..")
 	if err := config.Load(configFile); err != nil {
 		log.Fatal("Couldn't read ", *configFile, ": ", err)
 		os.Exit(1)
 	}
+	if *cloudwatchQuotaFraction < 0 || *cloudwatchQuotaFraction > 1 {
+		log.Fatal("cloudwatch-quota-fraction must be between 0 and 1")
+	}
 	if *verifyConfig {
 		log.Info("Config ", *configFile, " is valid")
 		os.Exit(0)
 	}
+	if *configRefreshInterval > 0 {
+		go refreshConfig(time.Duration(*configRefreshInterval) * time.Second)
+	}
 
 	cloudwatchSemaphore := make(chan struct{}, *cloudwatchConcurrency)
 	tagSemaphore := make(chan struct{}, *tagConcurrency)
 
 	registry := prometheus.NewRegistry()
+	collector := exporter.NewPrometheusCollector(nil)
 
 	log.Println("Startup completed")
 	//Variables to hold last scrape time
 	var now time.Time
 	//variable to hold total processing time.
 	var processingtimeTotal time.Duration
-	maxjoblength := 0
-	for _, discoveryJob := range config.Discovery.Jobs {
-		length := exporter.GetMetricDataInputLength(discoveryJob)
-		//S3 can have upto 1 day to day will need to address it in seprate block
-		//TBD
-		svc := exporter.SupportedServices.GetService(discoveryJob.Type)
-		if (maxjoblength < length) && !svc.IgnoreLength {
-			maxjoblength = length
-		}
-	}
-
-	//To aviod future timestamp issue we need make sure scrape intervel is atleast at the same level as that of highest job length
-	if *scrapingInterval < maxjoblength {
-		*scrapingInterval = maxjoblength
-	}
-
 	if *decoupledScraping {
 		go func() {
 			for {
 				t0 := time.Now()
-				newRegistry := prometheus.NewRegistry()
-				endtime := exporter.UpdateMetrics(config, newRegistry, now, *metricsPerQuery, *fips, *floatingTimeWindow, *labelsSnakeCase, cloudwatchSemaphore, tagSemaphore)
-				now = endtime
+				setLastScrapeStart(t0)
+				if *streamingMetrics {
+					collector, now = exporter.ScrapeMetrics(currentConfig(), now, *metricsPerQuery, *cloudwatchQuotaFraction, *fips, *floatingTimeWindow, *labelsSnakeCase, cloudwatchSemaphore, tagSemaphore)
+				} else {
+					newRegistry := prometheus.NewRegistry()
+					endtime := exporter.UpdateMetrics(currentConfig(), newRegistry, now, *metricsPerQuery, *cloudwatchQuotaFraction, *fips, *floatingTimeWindow, *labelsSnakeCase, cloudwatchSemaphore, tagSemaphore)
+					now = endtime
+					registry = newRegistry
+				}
 				log.Debug("Metrics scraped.")
-				registry = newRegistry
 				t1 := time.Now()
 				processingtime := t1.Sub(t0)
 				processingtimeTotal = processingtimeTotal + processingtime
+				interval := currentScrapingInterval()
 				if processingtimeTotal.Seconds() > 60.0 {
-					sleepinterval := *scrapingInterval - int(processingtimeTotal.Seconds())
+					sleepinterval := interval - int(processingtimeTotal.Seconds())
 					//reset processingtimeTotal
 					processingtimeTotal = 0
 					if sleepinterval <= 0 {
@@ -117,12 +252,12 @@ func main() {
 						continue
 					} else {
 						log.Debug("Sleeping smaller intervals to catchup with lag", sleepinterval)
-						time.Sleep(time.Duration(sleepinterval) * time.Second)
+						sleepOrRediscover(time.Duration(sleepinterval) * time.Second)
 					}
 
 				} else {
-					log.Debug("Sleeping at regular sleep interval ", *scrapingInterval)
-					time.Sleep(time.Duration(*scrapingInterval) * time.Second)
+					log.Debug("Sleeping at regular sleep interval ", interval)
+					sleepOrRediscover(time.Duration(interval) * time.Second)
 				}
 
 			}
@@ -140,10 +275,22 @@ func main() {
 		</html>`))
 	})
 
+	if *enableRediscover {
+		http.HandleFunc("/-/rediscover", rediscoverHandler)
+	}
+
 	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		if *streamingMetrics {
+			if !(*decoupledScraping) {
+				collector, _ = exporter.ScrapeMetrics(currentConfig(), now, *metricsPerQuery, *cloudwatchQuotaFraction, *fips, *floatingTimeWindow, *labelsSnakeCase, cloudwatchSemaphore, tagSemaphore)
+				log.Debug("Metrics scraped.")
+			}
+			exporter.StreamingHandler(collector).ServeHTTP(w, r)
+			return
+		}
 		if !(*decoupledScraping) {
 			newRegistry := prometheus.NewRegistry()
-			exporter.UpdateMetrics(config, newRegistry, now, *metricsPerQuery, *fips, *floatingTimeWindow, *labelsSnakeCase, cloudwatchSemaphore, tagSemaphore)
+			exporter.UpdateMetrics(currentConfig(), newRegistry, now, *metricsPerQuery, *cloudwatchQuotaFraction, *fips, *floatingTimeWindow, *labelsSnakeCase, cloudwatchSemaphore, tagSemaphore)
 			log.Debug("Metrics scraped.")
 			registry = newRegistry
 		}
@@ -153,5 +300,25 @@ func main() {
 		handler.ServeHTTP(w, r)
 	})
 
+	if *influxLineProtocol {
+		http.HandleFunc("/influx", func(w http.ResponseWriter, r *http.Request) {
+			if *streamingMetrics {
+				if !(*decoupledScraping) {
+					collector, _ = exporter.ScrapeMetrics(currentConfig(), now, *metricsPerQuery, *cloudwatchQuotaFraction, *fips, *floatingTimeWindow, *labelsSnakeCase, cloudwatchSemaphore, tagSemaphore)
+					log.Debug("Metrics scraped.")
+				}
+				exporter.InfluxHandler(exporter.NewRegistry(collector)).ServeHTTP(w, r)
+				return
+			}
+			if !(*decoupledScraping) {
+				newRegistry := prometheus.NewRegistry()
+				exporter.UpdateMetrics(currentConfig(), newRegistry, now, *metricsPerQuery, *cloudwatchQuotaFraction, *fips, *floatingTimeWindow, *labelsSnakeCase, cloudwatchSemaphore, tagSemaphore)
+				log.Debug("Metrics scraped.")
+				registry = newRegistry
+			}
+			exporter.InfluxHandler(registry).ServeHTTP(w, r)
+		})
+	}
+
 	log.Fatal(http.ListenAndServe(*addr, nil))
 }
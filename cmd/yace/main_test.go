@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ivx/yet-another-cloudwatch-exporter/pkg"
+)
+
+func TestCurrentScrapingInterval(t *testing.T) {
+	defer func() { config = exporter.ScrapeConf{} }()
+	withJobLength := func(length int) exporter.ScrapeConf {
+		return exporter.ScrapeConf{Discovery: exporter.Discovery{Jobs: []*exporter.Job{{Type: "rds", Length: length}}}}
+	}
+
+	config = withJobLength(120)
+	if interval := currentScrapingInterval(); interval != *scrapingInterval {
+		t.Fatalf("expected the scraping interval %d, got %d", *scrapingInterval, interval)
+	}
+
+	// A reloaded config with a longer job raises the interval, shortening the job lowers it again
+	config = withJobLength(3600)
+	if interval := currentScrapingInterval(); interval != 3600 {
+		t.Fatalf("expected the job length 3600, got %d", interval)
+	}
+	config = withJobLength(120)
+	if interval := currentScrapingInterval(); interval != *scrapingInterval {
+		t.Fatalf("expected the scraping interval %d, got %d", *scrapingInterval, interval)
+	}
+}
+
+func TestRediscoverHandler(t *testing.T) {
+	config = exporter.ScrapeConf{Discovery: exporter.Discovery{Jobs: []*exporter.Job{{Type: "rds"}}}}
+	defer func() { config = exporter.ScrapeConf{} }()
+
+	rediscoverRequest := func(method, url string) int {
+		recorder := httptest.NewRecorder()
+		rediscoverHandler(recorder, httptest.NewRequest(method, url, nil))
+		return recorder.Code
+	}
+	woken := func() bool {
+		select {
+		case <-rediscover:
+			return true
+		default:
+			return false
+		}
+	}
+
+	if code := rediscoverRequest(http.MethodGet, "/-/rediscover"); code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected %d for GET, got %d", http.StatusMethodNotAllowed, code)
+	}
+	if code := rediscoverRequest(http.MethodPost, "/-/rediscover?job=ec2"); code != http.StatusNotFound {
+		t.Fatalf("expected %d for an unknown job, got %d", http.StatusNotFound, code)
+	}
+	if woken() {
+		t.Fatal("rejected requests shouldn't wake the scraping loop")
+	}
+
+	setLastScrapeStart(time.Now().Add(-time.Duration(*scrapingInterval) * time.Second))
+	if code := rediscoverRequest(http.MethodPost, "/-/rediscover?job=rds"); code != http.StatusAccepted {
+		t.Fatalf("expected %d, got %d", http.StatusAccepted, code)
+	}
+	if !woken() {
+		t.Fatal("expected the scraping loop to be woken")
+	}
+
+	// Within half a scraping interval of the last scrape the next scrape isn't started early
+	setLastScrapeStart(time.Now())
+	if code := rediscoverRequest(http.MethodPost, "/-/rediscover"); code != http.StatusAccepted {
+		t.Fatalf("expected %d, got %d", http.StatusAccepted, code)
+	}
+	if woken() {
+		t.Fatal("expected the scraping loop not to be woken again")
+	}
+}
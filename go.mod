@@ -5,6 +5,8 @@ go 1.15
 require (
 	github.com/aws/aws-sdk-go v1.36.20
 	github.com/prometheus/client_golang v1.9.0
+	github.com/prometheus/client_model v0.2.0
+	github.com/prometheus/common v0.15.0
 	github.com/sirupsen/logrus v1.6.0
 	gopkg.in/yaml.v2 v2.3.0
 )
@@ -1,16 +1,21 @@
 package exporter
 
 import (
-	"math"
+	"fmt"
 	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
 	"github.com/aws/aws-sdk-go/service/sts"
 	log "github.com/sirupsen/logrus"
 )
 
-func scrapeAwsData(config ScrapeConf, now time.Time, metricsPerQuery int, fips, floatingTimeWindow bool, cloudwatchSemaphore, tagSemaphore chan struct{}) ([]*tagsData, []*cloudwatchData, *time.Time) {
+func scrapeAwsData(config ScrapeConf, now time.Time, metricsPerQuery int, cloudwatchQuotaFraction float64, fips, floatingTimeWindow bool, cloudwatchSemaphore, tagSemaphore chan struct{}) ([]*tagsData, []*cloudwatchData, *time.Time) {
 	mux := &sync.Mutex{}
 
 	cwData := make([]*cloudwatchData, 0)
@@ -18,61 +23,125 @@ func scrapeAwsData(config ScrapeConf, now time.Time, metricsPerQuery int, fips,
 	var endtime time.Time
 	var wg sync.WaitGroup
 
+	emptyMetrics.startScrape()
+	apiUsage.reset()
+	getMetricDataBudget.startScrape()
+	regions.startScrape()
+	staleData.startScrape()
+	opts := newSessionOptions(config)
+
+	var accountTags *accountTagsCache
+	if len(config.Discovery.ExportedAccountTags) > 0 {
+		accountTags = newAccountTagsCache(organizationsInterface{
+			client: createOrganizationsSession(Role{}, opts),
+		}, config.Discovery.ExportedAccountTags)
+	}
+
+	// Jobs sharing a role and region are scraped together, so their GetMetricData queries can share requests
+	discoveryJobs := make(map[discoveryJobKey][]*Job)
+	roles := make(map[string]Role)
 	for _, discoveryJob := range config.Discovery.Jobs {
 		for _, role := range discoveryJob.Roles {
-			for _, region := range discoveryJob.Regions {
-				wg.Add(1)
-				go func(discoveryJob *Job, region string, role Role) {
-					defer wg.Done()
-					clientSts := createStsSession(role)
-					result, err := clientSts.GetCallerIdentity(&sts.GetCallerIdentityInput{})
-					if err != nil {
-						log.Printf("Couldn't get account Id for role %s: %s\n", role.RoleArn, err.Error())
+			roles[role.key()] = role
+			for _, region := range role.scrapedRegions(discoveryJob.Regions) {
+				key := discoveryJobKey{role: role.key(), region: region}
+				discoveryJobs[key] = append(discoveryJobs[key], discoveryJob)
+			}
+		}
+	}
 
-					}
-					accountId := result.Account
+	// The budget is granted once every role and region submitted its jobs, so priorities hold across regions
+	budget := newBudgetRound(getMetricDataBudget, config.Discovery.GetMetricDataBudget, len(discoveryJobs))
 
-					clientCloudwatch := cloudwatchInterface{
-						client: createCloudwatchSession(&region, role, fips),
-					}
+	for key, jobs := range discoveryJobs {
+		wg.Add(1)
+		go func(jobs []*Job, region string, role Role) {
+			defer wg.Done()
+			clientSts := createStsSession(&region, role, opts)
+			regions.scraped(role, region)
+			result, err := clientSts.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+			if err != nil {
+				regions.probeFailed(role, region, fmt.Errorf("Couldn't get account Id for role %s: %w", role.RoleArn, err))
+				budget.leave()
+				failed := make(map[string]bool)
+				for _, job := range jobs {
+					failed[job.Type] = true
+				}
+				resources, metrics := staleData.useDiscoveryJobs(jobs, role, region, failed, time.Now(), nil, nil)
+				mux.Lock()
+				awsInfoData = append(awsInfoData, resources...)
+				cwData = append(cwData, metrics...)
+				mux.Unlock()
+				return
+			}
+			accountId := result.Account
 
-					clientTag := tagsInterface{
-						client:           createTagSession(&region, role, fips),
-						apiGatewayClient: createAPIGatewaySession(&region, role, fips),
-						asgClient:        createASGSession(&region, role, fips),
-						ec2Client:        createEC2Session(&region, role, fips),
-					}
-					var resources []*tagsData
-					var metrics []*cloudwatchData
-					resources, metrics, endtime = scrapeDiscoveryJobUsingMetricData(discoveryJob, region, accountId, config.Discovery.ExportedTagsOnMetrics, clientTag, clientCloudwatch, now, metricsPerQuery, floatingTimeWindow, tagSemaphore)
-					mux.Lock()
-					awsInfoData = append(awsInfoData, resources...)
-					cwData = append(cwData, metrics...)
-					mux.Unlock()
-				}(discoveryJob, region, role)
+			clientCloudwatch := cloudwatchInterface{
+				client: createCloudwatchSession(&region, role, fips, opts, cloudwatchQuotaFraction),
 			}
-		}
+
+			clientTag := tagsInterface{
+				client:             createTagSession(&region, role, fips, opts),
+				apiGatewayClient:   createAPIGatewaySession(&region, role, fips, opts),
+				asgClient:          createASGSession(&region, role, fips, opts),
+				ec2Client:          createEC2Session(&region, role, fips, opts),
+				dmsClient:          createDMSSession(&region, role, fips, opts),
+				mediaPackageClient: createMediaPackageSession(&region, role, fips, opts),
+				shieldClient:       createShieldSession(role, fips, opts),
+				elbv2Client:        createELBv2Session(&region, role, fips, opts),
+				rdsClient:          createRDSSession(&region, role, fips, opts),
+			}
+			clientQuotas := createServiceQuotasSession(&region, role, fips, opts)
+			resources, metrics, jobsEndtime, failed := scrapeDiscoveryJobsUsingMetricData(jobs, region, role, accountId, config.Discovery.ExportedTagsOnMetrics, budget, clientTag, clientCloudwatch, clientQuotas, now, metricsPerQuery, floatingTimeWindow, tagSemaphore)
+			accountTags.apply(accountId, resources, metrics)
+			for _, resource := range resources {
+				resource.RoleLabels = role.Labels
+			}
+			for _, metric := range metrics {
+				metric.RoleLabels = role.Labels
+			}
+			resources, metrics = staleData.useDiscoveryJobs(jobs, role, region, failed, time.Now(), resources, metrics)
+			mux.Lock()
+			awsInfoData = append(awsInfoData, resources...)
+			cwData = append(cwData, metrics...)
+			if !jobsEndtime.IsZero() {
+				endtime = jobsEndtime
+			}
+			mux.Unlock()
+		}(jobs, key.region, roles[key.role])
 	}
 
 	for _, staticJob := range config.Static {
 		for _, role := range staticJob.Roles {
-			for _, region := range staticJob.Regions {
+			for _, region := range role.scrapedRegions(staticJob.Regions) {
 				wg.Add(1)
 
 				go func(staticJob *Static, region string, role Role) {
 					defer wg.Done()
-					clientSts := createStsSession(role)
+					cacheKey := staleCacheKey{name: staticJob.Name, role: role.key(), region: region}
+					clientSts := createStsSession(&region, role, opts)
+					regions.scraped(role, region)
 					result, err := clientSts.GetCallerIdentity(&sts.GetCallerIdentityInput{})
 					if err != nil {
-						log.Printf("Couldn't get account Id for role %s: %s\n", role.RoleArn, err.Error())
+						regions.probeFailed(role, region, fmt.Errorf("Couldn't get account Id for role %s: %w", role.RoleArn, err))
+						_, metrics := staleData.use(cacheKey, staticJob.MaxStale, true, time.Now(), nil, nil)
+						mux.Lock()
+						cwData = append(cwData, metrics...)
+						mux.Unlock()
+						return
 					}
 					accountId := result.Account
 
 					clientCloudwatch := cloudwatchInterface{
-						client: createCloudwatchSession(&region, role, fips),
+						client: createCloudwatchSession(&region, role, fips, opts, cloudwatchQuotaFraction),
 					}
 
-					metrics := scrapeStaticJob(staticJob, region, accountId, clientCloudwatch, cloudwatchSemaphore)
+					metrics, failed := scrapeStaticJob(staticJob, region, role, accountId, clientCloudwatch, cloudwatchSemaphore)
+					accountTags.apply(accountId, nil, metrics)
+					for _, metric := range metrics {
+						metric.RoleLabels = role.Labels
+					}
+					_, metrics = staleData.use(cacheKey, staticJob.MaxStale, failed, time.Now(), nil, metrics)
 
 					mux.Lock()
 					cwData = append(cwData, metrics...)
@@ -82,10 +151,17 @@ func scrapeAwsData(config ScrapeConf, now time.Time, metricsPerQuery int, fips,
 		}
 	}
 	wg.Wait()
+	suppressedQueriesGauge.Set(float64(emptyMetrics.suppressedQueries()))
+	apiUsage.publish()
+	getMetricDataBudget.publish()
+	regions.publish()
+	staleData.publish()
+	clockSkew.publish()
 	return awsInfoData, cwData, &endtime
 }
 
-func scrapeStaticJob(resource *Static, region string, accountId *string, clientCloudwatch cloudwatchInterface, cloudwatchSemaphore chan struct{}) (cw []*cloudwatchData) {
+// scrapeStaticJob returns the data of the static job and whether any of its requests failed
+func scrapeStaticJob(resource *Static, region string, role Role, accountId *string, clientCloudwatch cloudwatchInterface, cloudwatchSemaphore chan struct{}) (cw []*cloudwatchData, failed bool) {
 	mux := &sync.Mutex{}
 	var wg sync.WaitGroup
 
@@ -99,6 +175,12 @@ func scrapeStaticJob(resource *Static, region string, accountId *string, clientC
 			defer func() {
 				<-cloudwatchSemaphore
 			}()
+			if regions.isDown(role, region) {
+				mux.Lock()
+				failed = true
+				mux.Unlock()
+				return
+			}
 
 			id := resource.Name
 			data := cloudwatchData{
@@ -108,6 +190,8 @@ func scrapeStaticJob(resource *Static, region string, accountId *string, clientC
 				Statistics:             metric.Statistics,
 				NilToZero:              metric.NilToZero,
 				AddCloudwatchTimestamp: metric.AddCloudwatchTimestamp,
+				PercentilesAsQuantiles: metric.PercentilesAsQuantiles,
+				ComputeAverage:         metric.ComputeAverage,
 				CustomTags:             resource.CustomTags,
 				Dimensions:             createStaticDimensions(resource.Dimensions),
 				Region:                 &region,
@@ -120,7 +204,16 @@ func scrapeStaticJob(resource *Static, region string, accountId *string, clientC
 				metric,
 			)
 
-			data.Points = clientCloudwatch.get(filter)
+			points, err := clientCloudwatch.get(filter)
+			apiUsage.add(resource.Name, accountId, getMetricStatisticsAPI, 1)
+			if err != nil {
+				regions.report(role, region, err)
+				mux.Lock()
+				failed = true
+				mux.Unlock()
+				return
+			}
+			data.Points = points
 
 			if data.Points != nil {
 				mux.Lock()
@@ -130,7 +223,7 @@ func scrapeStaticJob(resource *Static, region string, accountId *string, clientC
 		}()
 	}
 	wg.Wait()
-	return cw
+	return cw, failed
 }
 
 func GetMetricDataInputLength(job *Job) int {
@@ -158,89 +251,397 @@ func getMetricDataForQueries(
 	tagsOnMetrics exportedTagsOnMetrics,
 	clientCloudwatch cloudwatchInterface,
 	resources []*tagsData,
-	tagSemaphore chan struct{}) []cloudwatchData {
+	tagSemaphore chan struct{}) ([]cloudwatchData, error) {
+	// The metrics of the job are listed concurrently, every metric is paginated on its own
+	metricDatas := make([][]cloudwatchData, len(discoveryJob.Metrics))
+	errs := make([]error, len(discoveryJob.Metrics))
+	var wg sync.WaitGroup
+	for i, metric := range discoveryJob.Metrics {
+		wg.Add(1)
+		go func(i int, metric *Metric) {
+			defer wg.Done()
+			// Get the full list of metrics
+			// This includes, for this metric the possible combinations
+			// of dimensions and value of dimensions with data
+			tagSemaphore <- struct{}{}
+			metricsList, requests, err := getFullMetricsList(svc.Namespace, metric, clientCloudwatch)
+			<-tagSemaphore
+			apiUsage.add(discoveryJob.Type, accountId, listMetricsAPI, requests)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			if len(resources) == 0 {
+				log.Debugf("No resources for metric %s on %s job", metric.Name, svc.Namespace)
+			}
+			metricDatas[i] = getFilteredMetricDatas(region, accountId, discoveryJob.Type, discoveryJob.CustomTags, tagsOnMetrics, svc.DimensionRegexps, resources, metricsList.Metrics, metric)
+		}(i, metric)
+	}
+	wg.Wait()
+
 	var getMetricDatas []cloudwatchData
+	for i, m := range metricDatas {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		getMetricDatas = append(getMetricDatas, m...)
+	}
+	return getMetricDatas, nil
+}
+
+type discoveryJobKey struct {
+	role   string
+	region string
+}
 
-	// For every metric of the job
-	for _, metric := range discoveryJob.Metrics {
-		// Get the full list of metrics
-		// This includes, for this metric the possible combinations
-		// of dimensions and value of dimensions with data
-		tagSemaphore <- struct{}{}
-
-		metricsList := getFullMetricsList(svc.Namespace, metric, clientCloudwatch)
-		<-tagSemaphore
-		if len(resources) == 0 {
-			log.Debugf("No resources for metric %s on %s job", metric.Name, svc.Namespace)
+// metricDataWindow is the time window of a GetMetricData request, only queries of the same window can share a request
+type metricDataWindow struct {
+	length int
+	delay  int
+	// Queries with offsets are anchored at the current time truncated to their period instead of the last scrape
+	startOffset time.Duration
+	endOffset   time.Duration
+	period      int64
+}
+
+type metricDataBatch struct {
+	window      metricDataWindow
+	metricDatas []cloudwatchData
+}
+
+// createMetricDataBatches splits the queries of every window into batches of at most metricsPerQuery queries
+func createMetricDataBatches(getMetricDatas map[metricDataWindow][]cloudwatchData, metricsPerQuery int) []metricDataBatch {
+	var batches []metricDataBatch
+	for window, metricDatas := range getMetricDatas {
+		for i := 0; i < len(metricDatas); i += metricsPerQuery {
+			end := i + metricsPerQuery
+			if end > len(metricDatas) {
+				end = len(metricDatas)
+			}
+			batches = append(batches, metricDataBatch{window: window, metricDatas: metricDatas[i:end]})
 		}
-		getMetricDatas = append(getMetricDatas, getFilteredMetricDatas(region, accountId, discoveryJob.Type, discoveryJob.CustomTags, tagsOnMetrics, svc.DimensionRegexps, resources, metricsList.Metrics, metric)...)
 	}
-	return getMetricDatas
+	return batches
+}
+
+// budgetJobKey identifies a job of an account, role and region to the budget tracker
+func budgetJobKey(accountId *string, role Role, region string, job *Job) string {
+	return fmt.Sprintf("%s/%s/%s/%s", aws.StringValue(accountId), role.key(), region, job.Type)
 }
 
-func scrapeDiscoveryJobUsingMetricData(
-	job *Job,
+func scrapeDiscoveryJobsUsingMetricData(
+	jobs []*Job,
 	region string,
+	role Role,
 	accountId *string,
 	tagsOnMetrics exportedTagsOnMetrics,
+	budget *budgetRound,
 	clientTag tagsInterface,
-	clientCloudwatch cloudwatchInterface, now time.Time,
+	clientCloudwatch cloudwatchInterface,
+	clientQuotas servicequotasiface.ServiceQuotasAPI, now time.Time,
 	metricsPerQuery int, floatingTimeWindow bool,
-	tagSemaphore chan struct{}) (resources []*tagsData, cw []*cloudwatchData, endtime time.Time) {
-
-	// Add the info tags of all the resources
-	tagSemaphore <- struct{}{}
-	resources, err := clientTag.get(job, region)
-	<-tagSemaphore
-	if err != nil {
-		log.Printf("Couldn't describe resources for region %s: %s\n", region, err.Error())
-		return
+	tagSemaphore chan struct{}) (resources []*tagsData, cw []*cloudwatchData, endtime time.Time, failed map[string]bool) {
+
+	// failed has the job types whose data is incomplete, so it isn't cached as the data of a successful scrape
+	failed = make(map[string]bool)
+	jobsMetricDatas := make([][]cloudwatchData, len(jobs))
+	jobsResources := make([][]*tagsData, len(jobs))
+	jobsDroppedResources := make([]map[string]bool, len(jobs))
+	skipped := make([]bool, len(jobs))
+	mux := &sync.Mutex{}
+	var wg sync.WaitGroup
+	fail := func(jobType string) {
+		mux.Lock()
+		defer mux.Unlock()
+		failed[jobType] = true
 	}
 
-	svc := SupportedServices.GetService(job.Type)
-	getMetricDatas := getMetricDataForQueries(job, svc, region, accountId, tagsOnMetrics, clientCloudwatch, resources, tagSemaphore)
-	maxMetricCount := metricsPerQuery
-	metricDataLength := len(getMetricDatas)
-	length := GetMetricDataInputLength(job)
-	partition := int(math.Ceil(float64(metricDataLength) / float64(maxMetricCount)))
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(i int, job *Job) {
+			defer wg.Done()
+			if regions.isDown(role, region) {
+				fail(job.Type)
+				return
+			}
+			if budget != nil && getMetricDataBudget.deferred(accountId, budgetJobKey(accountId, role, region, job)) {
+				log.Debugf("Deferring %s job in %s, it was skipped for the GetMetricData budget", job.Type, region)
+				skipped[i] = true
+				fail(job.Type)
+				return
+			}
 
-	mux := &sync.Mutex{}
-	var wg sync.WaitGroup
-	wg.Add(partition)
+			// Add the info tags of all the resources
+			jobResources, err := clientTag.get(job, region, tagSemaphore)
+			if err != nil {
+				regions.report(role, region, fmt.Errorf("Couldn't describe resources for region %s: %w", region, err))
+				fail(job.Type)
+				return
+			}
+
+			jobResources = limitResources(job, region, jobResources)
 
-	if metricDataLength == 0 {
-		log.Debugf("No metrics data for %s", job.Type)
+			svc := SupportedServices.GetService(job.Type)
+			jobMetricDatas, err := getMetricDataForQueries(job, svc, region, accountId, tagsOnMetrics, clientCloudwatch, jobResources, tagSemaphore)
+			if err != nil {
+				regions.report(role, region, err)
+				fail(job.Type)
+				return
+			}
+			var seriesPerResource map[string]int
+			if job.ZeroPlaceholders {
+				seriesPerResource = countSeriesPerResource(jobMetricDatas)
+			}
+			jobMetricDatas = limitSeries(job, region, jobMetricDatas)
+			jobMetricDatas = emptyMetrics.filter(job, jobMetricDatas)
+			if job.ZeroPlaceholders {
+				jobsDroppedResources[i] = droppedResources(seriesPerResource, jobMetricDatas)
+			}
+			if len(jobMetricDatas) == 0 {
+				log.Debugf("No metrics data for %s", job.Type)
+			}
+			jobsMetricDatas[i] = jobMetricDatas
+			jobsResources[i] = jobResources
+
+			mux.Lock()
+			resources = append(resources, jobResources...)
+			mux.Unlock()
+		}(i, job)
+	}
+	wg.Wait()
+
+	// Jobs with a higher priority take the budget first, jobs not fitting into the budget are skipped this scrape
+	requests := make([]*budgetRequest, len(jobs))
+	submitted := make([]*budgetRequest, 0, len(jobs))
+	for i, job := range jobs {
+		if skipped[i] {
+			continue
+		}
+		requests[i] = &budgetRequest{
+			job:       budgetJobKey(accountId, role, region, job),
+			accountId: accountId,
+			priority:  job.Priority,
+			metrics:   len(jobsMetricDatas[i]),
+		}
+		submitted = append(submitted, requests[i])
+	}
+	budget.submit(submitted)
+	getMetricDatas := make(map[metricDataWindow][]cloudwatchData)
+	for i, job := range jobs {
+		jobMetricDatas := jobsMetricDatas[i]
+		if skipped[i] {
+			continue
+		}
+		if !requests[i].granted {
+			log.Warningf("Skipping %s job in %s, requesting %d metrics would exceed the GetMetricData budget", job.Type, region, len(jobMetricDatas))
+			skipped[i] = true
+			failed[job.Type] = true
+			continue
+		}
+		apiUsage.add(job.Type, accountId, getMetricDataAPI, len(jobMetricDatas))
+		jobWindow := metricDataWindow{length: GetMetricDataInputLength(job), delay: job.Delay}
+		for _, getMetricData := range jobMetricDatas {
+			window := jobWindow
+			if getMetricData.StartOffset != 0 {
+				window = metricDataWindow{startOffset: getMetricData.StartOffset, endOffset: getMetricData.EndOffset, period: getMetricData.Period}
+			}
+			getMetricDatas[window] = append(getMetricDatas[window], getMetricData)
+		}
 	}
 
-	for i := 0; i < metricDataLength; i += maxMetricCount {
-		go func(i int) {
+	for _, batch := range createMetricDataBatches(getMetricDatas, metricsPerQuery) {
+		wg.Add(1)
+		go func(batch metricDataBatch) {
 			defer wg.Done()
-			end := i + maxMetricCount
-			if end > metricDataLength {
-				end = metricDataLength
+			filter := createGetMetricDataInput(batch.metricDatas, batch.window.length, batch.window.delay, now, floatingTimeWindow)
+			data, err := clientCloudwatch.getMetricData(filter)
+			if err != nil {
+				regions.report(role, region, err)
+			}
+			mux.Lock()
+			defer mux.Unlock()
+			if err != nil {
+				for _, getMetricData := range batch.metricDatas {
+					failed[*getMetricData.Namespace] = true
+				}
 			}
-			filter := createGetMetricDataInput(getMetricDatas[i:end], &svc.Namespace, length, job.Delay, now, floatingTimeWindow)
-			data := clientCloudwatch.getMetricData(filter)
 			if data != nil {
 				for _, MetricDataResult := range data.MetricDataResults {
-					getMetricData, err := findGetMetricDataById(getMetricDatas[i:end], *MetricDataResult.Id)
+					getMetricData, err := findGetMetricDataById(batch.metricDatas, *MetricDataResult.Id)
 					if err == nil {
 						if len(MetricDataResult.Values) != 0 {
 							getMetricData.GetMetricDataPoint = MetricDataResult.Values[0]
 							getMetricData.GetMetricDataTimestamps = MetricDataResult.Timestamps[0]
 						}
-						mux.Lock()
 						cw = append(cw, &getMetricData)
-						mux.Unlock()
 					}
 				}
 			}
-			endtime = *filter.EndTime
-		}(i)
+			if batch.window.startOffset == 0 {
+				endtime = *filter.EndTime
+			}
+		}(batch)
 	}
 	//here set end time as start time
 	wg.Wait()
-	return resources, cw, endtime
+	emptyMetrics.record(cw)
+
+	for _, job := range jobs {
+		if len(job.ServiceQuotas) > 0 && !failed[job.Type] {
+			quotas := getCachedUsageQuotas(clientQuotas, role, region, job.ServiceQuotas, time.Now())
+			cw = append(cw, createServiceQuotaMetrics(job, quotas, cw, now)...)
+		}
+	}
+
+	if !regions.isDown(role, region) {
+		for i, job := range jobs {
+			if job.ZeroPlaceholders && !skipped[i] && !failed[job.Type] {
+				cw = append(cw, createZeroPlaceholders(job, region, accountId, tagsOnMetrics, jobsResources[i], jobsDroppedResources[i], cw, now)...)
+			}
+		}
+	}
+	return resources, cw, endtime, failed
+}
+
+// createZeroPlaceholders creates a zero datapoint of the metrics of the job for the resources without any datapoint, so
+// idle resources can be told apart from missing ones. Resources with series dropped by maxSeries or suppressed for
+// returning no datapoints are left out, they aren't known to be idle. Only Sum and SampleCount are 0 for idle resources,
+// other statistics only get a placeholder with nilToZero. Placeholders have the dimensions the resource is matched by,
+// so they have the labels of the series once the resource has datapoints.
+func createZeroPlaceholders(job *Job, region string, accountId *string, tagsOnMetrics exportedTagsOnMetrics, resources []*tagsData, dropped map[string]bool, cw []*cloudwatchData, now time.Time) []*cloudwatchData {
+	withDatapoints := make(map[string]bool)
+	for _, data := range cw {
+		if data.GetMetricDataPoint != nil && *data.Namespace == job.Type {
+			withDatapoints[*data.ID] = true
+		}
+	}
+
+	dimensionRegexps := SupportedServices.GetService(job.Type).DimensionRegexps
+	var placeholders []*cloudwatchData
+	for _, resource := range resources {
+		if withDatapoints[*resource.ID] || dropped[*resource.ID] {
+			continue
+		}
+		dimensions := resourceDimensions(dimensionRegexps, resource)
+		for _, metric := range job.Metrics {
+			for _, statistic := range metric.Statistics {
+				if statistic != "Sum" && statistic != "SampleCount" && !aws.BoolValue(metric.NilToZero) {
+					continue
+				}
+				zero := float64(0)
+				placeholders = append(placeholders, &cloudwatchData{
+					ID:                      resource.ID,
+					Metric:                  &metric.Name,
+					Namespace:               &job.Type,
+					Statistics:              []string{statistic},
+					NilToZero:               metric.NilToZero,
+					PercentilesAsQuantiles:  metric.PercentilesAsQuantiles,
+					ComputeAverage:          metric.ComputeAverage,
+					Tags:                    resource.metricTags(tagsOnMetrics),
+					CustomTags:              job.CustomTags,
+					Dimensions:              dimensions,
+					Region:                  &region,
+					AccountId:               accountId,
+					GetMetricDataPoint:      &zero,
+					GetMetricDataTimestamps: &now,
+				})
+			}
+		}
+	}
+	return placeholders
+}
+
+// resourceDimensions returns the dimensions the ARN of a resource is matched to the series of the resource by
+func resourceDimensions(dimensionRegexps []*string, resource *tagsData) []*cloudwatch.Dimension {
+	var dimensions []*cloudwatch.Dimension
+	seen := make(map[string]bool)
+	for _, dr := range dimensionRegexps {
+		dimensionRegexp := regexp.MustCompile(*dr)
+		match := dimensionRegexp.FindStringSubmatch(*resource.ID)
+		if match == nil {
+			continue
+		}
+		for i, name := range dimensionRegexp.SubexpNames() {
+			if i == 0 || name == "" {
+				continue
+			}
+			name = subexpToDimensionName(name)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			dimensions = append(dimensions, &cloudwatch.Dimension{Name: aws.String(name), Value: aws.String(match[i])})
+		}
+	}
+	return dimensions
+}
+
+func countSeriesPerResource(getMetricDatas []cloudwatchData) map[string]int {
+	series := make(map[string]int)
+	for _, getMetricData := range getMetricDatas {
+		series[*getMetricData.ID]++
+	}
+	return series
+}
+
+// droppedResources returns the resources which lost series between the count and the queried series
+func droppedResources(seriesPerResource map[string]int, queried []cloudwatchData) map[string]bool {
+	remaining := countSeriesPerResource(queried)
+	dropped := make(map[string]bool)
+	for id, series := range seriesPerResource {
+		if remaining[id] < series {
+			dropped[id] = true
+		}
+	}
+	return dropped
+}
+
+// limitResources keeps the first job.MaxResources resources ordered by ID, so the same resources are kept on every scrape
+func limitResources(job *Job, region string, resources []*tagsData) []*tagsData {
+	if job.MaxResources == 0 || len(resources) <= job.MaxResources {
+		return resources
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		return *resources[i].ID < *resources[j].ID
+	})
+	dropped := len(resources) - job.MaxResources
+	log.Warningf("Dropping %d of %d resources of %s job in %s, maxResources is %d", dropped, len(resources), job.Type, region, job.MaxResources)
+	droppedResourcesCounter.WithLabelValues(job.Type).Add(float64(dropped))
+	return resources[:job.MaxResources]
+}
+
+// limitSeries keeps the first job.MaxSeries series ordered by resource, metric, dimensions and statistic
+func limitSeries(job *Job, region string, getMetricDatas []cloudwatchData) []cloudwatchData {
+	if job.MaxSeries == 0 || len(getMetricDatas) <= job.MaxSeries {
+		return getMetricDatas
+	}
+	type keyedMetricData struct {
+		key  string
+		data cloudwatchData
+	}
+	keyed := make([]keyedMetricData, len(getMetricDatas))
+	for i, getMetricData := range getMetricDatas {
+		keyed[i] = keyedMetricData{key: seriesKey(getMetricData), data: getMetricData}
+	}
+	sort.Slice(keyed, func(i, j int) bool {
+		return keyed[i].key < keyed[j].key
+	})
+	for i := range keyed {
+		getMetricDatas[i] = keyed[i].data
+	}
+	dropped := len(getMetricDatas) - job.MaxSeries
+	log.Warningf("Dropping %d of %d series of %s job in %s, maxSeries is %d", dropped, len(getMetricDatas), job.Type, region, job.MaxSeries)
+	droppedSeriesCounter.WithLabelValues(job.Type).Add(float64(dropped))
+	return getMetricDatas[:job.MaxSeries]
+}
+
+func seriesKey(getMetricData cloudwatchData) string {
+	dimensions := make([]string, 0, len(getMetricData.Dimensions))
+	for _, dimension := range getMetricData.Dimensions {
+		dimensions = append(dimensions, *dimension.Name+"="+*dimension.Value)
+	}
+	sort.Strings(dimensions)
+	return strings.Join([]string{*getMetricData.ID, *getMetricData.Metric, strings.Join(dimensions, ","), strings.Join(getMetricData.Statistics, ",")}, "|")
 }
 
 func (r tagsData) filterThroughTags(filterTags []Tag) bool {
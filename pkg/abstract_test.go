@@ -2,6 +2,12 @@ package exporter
 
 import (
 	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
 )
 
 func TestFilterThroughTags(t *testing.T) {
@@ -20,3 +26,160 @@ func TestFilterThroughTags(t *testing.T) {
 		t.Fatalf("\nexpected: %t\nactual:  %t", expected, actual)
 	}
 }
+
+func TestCreateMetricDataBatches(t *testing.T) {
+	short := metricDataWindow{length: 300, delay: 0}
+	long := metricDataWindow{length: 86400, delay: 0}
+	getMetricDatas := map[metricDataWindow][]cloudwatchData{
+		short: make([]cloudwatchData, 5),
+		long:  make([]cloudwatchData, 2),
+	}
+
+	batches := createMetricDataBatches(getMetricDatas, 2)
+
+	batchSizes := make(map[metricDataWindow][]int)
+	for _, batch := range batches {
+		batchSizes[batch.window] = append(batchSizes[batch.window], len(batch.metricDatas))
+	}
+	equals(t, []int{2, 2, 1}, batchSizes[short])
+	equals(t, []int{2}, batchSizes[long])
+}
+
+func TestLimitResources(t *testing.T) {
+	job := &Job{Type: "ec2", MaxResources: 2}
+	resources := []*tagsData{
+		{ID: aws.String("arn:aws:ec2:us-east-1:123123123123:instance/i-3")},
+		{ID: aws.String("arn:aws:ec2:us-east-1:123123123123:instance/i-1")},
+		{ID: aws.String("arn:aws:ec2:us-east-1:123123123123:instance/i-2")},
+	}
+
+	limited := limitResources(job, "us-east-1", resources)
+
+	equals(t, 2, len(limited))
+	equals(t, "arn:aws:ec2:us-east-1:123123123123:instance/i-1", *limited[0].ID)
+	equals(t, "arn:aws:ec2:us-east-1:123123123123:instance/i-2", *limited[1].ID)
+	equals(t, 3, len(limitResources(&Job{Type: "ec2"}, "us-east-1", resources)))
+}
+
+func TestLimitSeries(t *testing.T) {
+	job := &Job{Type: "ec2", MaxSeries: 1}
+	getMetricDatas := []cloudwatchData{
+		{
+			ID:         aws.String("i-1"),
+			Metric:     aws.String("CPUUtilization"),
+			Statistics: []string{"Maximum"},
+			Dimensions: []*cloudwatch.Dimension{{Name: aws.String("InstanceId"), Value: aws.String("i-1")}},
+		},
+		{
+			ID:         aws.String("i-1"),
+			Metric:     aws.String("CPUUtilization"),
+			Statistics: []string{"Average"},
+			Dimensions: []*cloudwatch.Dimension{{Name: aws.String("InstanceId"), Value: aws.String("i-1")}},
+		},
+	}
+
+	limited := limitSeries(job, "us-east-1", getMetricDatas)
+
+	equals(t, 1, len(limited))
+	equals(t, []string{"Average"}, limited[0].Statistics)
+}
+
+func TestCreateZeroPlaceholders(t *testing.T) {
+	job := &Job{
+		Type:             "ec2",
+		ZeroPlaceholders: true,
+		Metrics: []*Metric{
+			{Name: "CPUUtilization", Statistics: []string{"Average", "Maximum"}},
+			{Name: "NetworkPacketsIn", Statistics: []string{"Sum", "SampleCount", "p99"}},
+			{Name: "StatusCheckFailed", Statistics: []string{"Maximum"}, NilToZero: aws.Bool(true)},
+		},
+	}
+	resources := []*tagsData{
+		{ID: aws.String("i-1"), Namespace: aws.String("ec2")},
+		{ID: aws.String("i-2"), Namespace: aws.String("ec2")},
+		{ID: aws.String("i-3"), Namespace: aws.String("ec2")},
+	}
+	cw := []*cloudwatchData{
+		{ID: aws.String("i-1"), Namespace: aws.String("ec2"), GetMetricDataPoint: aws.Float64(42)},
+		{ID: aws.String("i-2"), Namespace: aws.String("ec2")},
+	}
+	// i-3 lost series to maxSeries, it isn't known to be idle
+	seriesPerResource := countSeriesPerResource([]cloudwatchData{{ID: aws.String("i-2")}, {ID: aws.String("i-3")}, {ID: aws.String("i-3")}})
+	dropped := droppedResources(seriesPerResource, []cloudwatchData{{ID: aws.String("i-2")}, {ID: aws.String("i-3")}})
+	equals(t, map[string]bool{"i-3": true}, dropped)
+
+	placeholders := createZeroPlaceholders(job, "us-east-1", aws.String("123123123123"), nil, resources, dropped, cw, time.Now())
+
+	equals(t, 3, len(placeholders))
+	for _, placeholder := range placeholders {
+		equals(t, "i-2", *placeholder.ID)
+		equals(t, float64(0), *placeholder.GetMetricDataPoint)
+	}
+	equals(t, []string{"Sum"}, placeholders[0].Statistics)
+	equals(t, []string{"SampleCount"}, placeholders[1].Statistics)
+	equals(t, []string{"Maximum"}, placeholders[2].Statistics)
+	equals(t, "StatusCheckFailed", *placeholders[2].Metric)
+}
+
+func TestZeroPlaceholderLabels(t *testing.T) {
+	job := &Job{
+		Type:             "ec2",
+		ZeroPlaceholders: true,
+		Metrics:          []*Metric{{Name: "NetworkPacketsIn", Statistics: []string{"Sum"}}},
+	}
+	idle := &tagsData{ID: aws.String("arn:aws:ec2:us-east-1:123123123123:instance/i-2"), Namespace: aws.String("ec2")}
+	now := time.Now()
+
+	placeholders := createZeroPlaceholders(job, "us-east-1", aws.String("123123123123"), nil, []*tagsData{idle}, nil, nil, now)
+	equals(t, 1, len(placeholders))
+
+	// The series of the resource once it has datapoints
+	series := &cloudwatchData{
+		ID:                      idle.ID,
+		Metric:                  aws.String("NetworkPacketsIn"),
+		Namespace:               aws.String("ec2"),
+		Statistics:              []string{"Sum"},
+		Dimensions:              []*cloudwatch.Dimension{{Name: aws.String("InstanceId"), Value: aws.String("i-2")}},
+		Region:                  aws.String("us-east-1"),
+		AccountId:               aws.String("123123123123"),
+		GetMetricDataPoint:      aws.Float64(42),
+		GetMetricDataTimestamps: &now,
+	}
+
+	placeholder := labelsOf(migrateCloudwatchToPrometheus(placeholders, false)[0])
+	equals(t, labelsOf(migrateCloudwatchToPrometheus([]*cloudwatchData{series}, false)[0]), placeholder)
+	equals(t, "i-2", placeholder["dimension_InstanceId"])
+}
+
+type mockCloudwatchClient struct {
+	cloudwatchiface.CloudWatchAPI
+	err error
+}
+
+func (m mockCloudwatchClient) GetMetricStatistics(input *cloudwatch.GetMetricStatisticsInput) (*cloudwatch.GetMetricStatisticsOutput, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &cloudwatch.GetMetricStatisticsOutput{Datapoints: []*cloudwatch.Datapoint{{Sum: aws.Float64(1), Timestamp: aws.Time(time.Now())}}}, nil
+}
+
+func TestScrapeStaticJobFailed(t *testing.T) {
+	job := &Static{
+		Name:      "requests",
+		Namespace: "AWS/ELB",
+		Metrics:   []*Metric{{Name: "RequestCount", Statistics: []string{"Sum"}, Period: 60, Length: 300}},
+	}
+	semaphore := make(chan struct{}, 1)
+	regions.startScrape()
+
+	cw, failed := scrapeStaticJob(job, "eu-west-1", Role{}, aws.String("123123123123"), cloudwatchInterface{client: mockCloudwatchClient{}}, semaphore)
+	equals(t, 1, len(cw))
+	equals(t, false, failed)
+
+	// A denied request fails the job, without taking the region down
+	denied := awserr.New("AccessDenied", "not authorized to perform cloudwatch:GetMetricStatistics", nil)
+	cw, failed = scrapeStaticJob(job, "eu-west-1", Role{}, aws.String("123123123123"), cloudwatchInterface{client: mockCloudwatchClient{err: denied}}, semaphore)
+	equals(t, 0, len(cw))
+	equals(t, true, failed)
+	equals(t, false, regions.isDown(Role{}, "eu-west-1"))
+}
@@ -0,0 +1,64 @@
+package exporter
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+const (
+	getMetricDataAPI       = "GetMetricData"
+	getMetricStatisticsAPI = "GetMetricStatistics"
+	listMetricsAPI         = "ListMetrics"
+)
+
+// CloudWatch list prices in US dollars per metric requested for GetMetricData and per request for the other apis
+// https://aws.amazon.com/cloudwatch/pricing/
+var apiPrices = map[string]float64{
+	getMetricDataAPI:       0.01 / 1000,
+	getMetricStatisticsAPI: 0.01 / 1000,
+	listMetricsAPI:         0.01 / 1000,
+}
+
+// apiUsage counts the billed units of the CloudWatch APIs in the current scrape
+var apiUsage = newAPIUsageRecorder()
+
+type apiUsageKey struct {
+	jobType   string
+	accountId string
+	api       string
+}
+
+type apiUsageRecorder struct {
+	mux   sync.Mutex
+	units map[apiUsageKey]int
+}
+
+func newAPIUsageRecorder() *apiUsageRecorder {
+	return &apiUsageRecorder{
+		units: make(map[apiUsageKey]int),
+	}
+}
+
+func (u *apiUsageRecorder) reset() {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+	u.units = make(map[apiUsageKey]int)
+}
+
+// add records billed units of an api, metrics requested for GetMetricData and requests for the other apis
+func (u *apiUsageRecorder) add(jobType string, accountId *string, api string, units int) {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+	u.units[apiUsageKey{jobType: jobType, accountId: aws.StringValue(accountId), api: api}] += units
+}
+
+// publish sets the estimated cost gauge to the cost of the recorded usage
+func (u *apiUsageRecorder) publish() {
+	u.mux.Lock()
+	defer u.mux.Unlock()
+	estimatedCostGauge.Reset()
+	for key, units := range u.units {
+		estimatedCostGauge.WithLabelValues(key.jobType, key.accountId, key.api).Set(float64(units) * apiPrices[key.api])
+	}
+}
@@ -0,0 +1,24 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestAPIUsageRecorder(t *testing.T) {
+	usage := newAPIUsageRecorder()
+	usage.add("ec2", aws.String("123123123123"), getMetricDataAPI, 1500)
+	usage.add("ec2", aws.String("123123123123"), getMetricDataAPI, 500)
+	usage.add("ec2", aws.String("123123123123"), listMetricsAPI, 3)
+
+	usage.publish()
+
+	equals(t, 2000*apiPrices[getMetricDataAPI], testutil.ToFloat64(estimatedCostGauge.WithLabelValues("ec2", "123123123123", getMetricDataAPI)))
+	equals(t, 3*apiPrices[listMetricsAPI], testutil.ToFloat64(estimatedCostGauge.WithLabelValues("ec2", "123123123123", listMetricsAPI)))
+
+	usage.reset()
+	usage.publish()
+	equals(t, 0, testutil.CollectAndCount(estimatedCostGauge))
+}
@@ -8,10 +8,11 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	awsendpoints "github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
@@ -39,38 +40,43 @@ type cloudwatchData struct {
 	AddCloudwatchTimestamp  *bool
 	CustomTags              []Tag
 	Tags                    []Tag
+	AccountTags             []Tag
+	RoleLabels              map[string]string
 	Dimensions              []*cloudwatch.Dimension
 	Region                  *string
 	AccountId               *string
 	Period                  int64
+	PercentilesAsQuantiles  bool
+	ComputeAverage          bool
+	StartOffset             time.Duration
+	EndOffset               time.Duration
 }
 
 var labelMap = make(map[string][]string)
 
-func createStsSession(role Role) *sts.STS {
+// createStsSession creates the STS client of a region, its regional endpoint makes getting the account id a probe of
+// the region
+func createStsSession(region *string, role Role, opts sessionOptions) *sts.STS {
 	sess := session.Must(session.NewSessionWithOptions(session.Options{
 		SharedConfigState: session.SharedConfigEnable,
 	}))
+	addUserAgent(sess, opts.userAgent)
 	maxStsRetries := 5
-	config := &aws.Config{MaxRetries: &maxStsRetries}
+	config := &aws.Config{Region: region, MaxRetries: &maxStsRetries, STSRegionalEndpoint: awsendpoints.RegionalSTSEndpoint}
 	if log.IsLevelEnabled(log.DebugLevel) {
 		config.LogLevel = aws.LogLevel(aws.LogDebugWithHTTPBody)
 	}
-	if role.RoleArn != "" {
-		config.Credentials = stscreds.NewCredentials(sess, role.RoleArn, func(p *stscreds.AssumeRoleProvider) {
-			if role.ExternalID != "" {
-				p.ExternalID = aws.String(role.ExternalID)
-			}
-		})
-	}
+	config.Credentials = roleCredentials(sess, role, opts)
+	setEndpoint(config, "sts", opts)
 	return sts.New(sess, config)
 }
 
-func createCloudwatchSession(region *string, role Role, fips bool) *cloudwatch.CloudWatch {
+func createCloudwatchSession(region *string, role Role, fips bool, opts sessionOptions, cloudwatchQuotaFraction float64) *cloudwatch.CloudWatch {
 	sess := session.Must(session.NewSessionWithOptions(session.Options{
 		SharedConfigState: session.SharedConfigEnable,
 		Config:            aws.Config{Region: aws.String(*region)},
 	}))
+	addUserAgent(sess, opts.userAgent)
 
 	maxCloudwatchRetries := 5
 
@@ -86,23 +92,30 @@ func createCloudwatchSession(region *string, role Role, fips bool) *cloudwatch.C
 		config.LogLevel = aws.LogLevel(aws.LogDebugWithHTTPBody)
 	}
 
-	if role.RoleArn != "" {
-		config.Credentials = stscreds.NewCredentials(sess, role.RoleArn, func(p *stscreds.AssumeRoleProvider) {
-			if role.ExternalID != "" {
-				p.ExternalID = aws.String(role.ExternalID)
-			}
-		})
-	}
+	config.Credentials = roleCredentials(sess, role, opts)
+
+	setEndpoint(config, "cloudwatch", opts)
 
-	return cloudwatch.New(sess, config)
+	client := cloudwatch.New(sess, config)
+	skipDownRegion(&client.Handlers, role, *region)
+	observeClockSkew(&client.Handlers)
+	if cloudwatchQuotaFraction > 0 {
+		rateLimitCloudwatch(&client.Handlers, getCloudwatchRateLimiters(region, role, fips, opts, cloudwatchQuotaFraction))
+	}
+	return client
 }
 
 func createGetMetricStatisticsInput(dimensions []*cloudwatch.Dimension, namespace *string, metric *Metric) (output *cloudwatch.GetMetricStatisticsInput) {
 	period := int64(metric.Period)
 	length := metric.Length
 	delay := metric.Delay
-	endTime := time.Now().Add(-time.Duration(delay) * time.Second)
-	startTime := time.Now().Add(-(time.Duration(length) + time.Duration(delay)) * time.Second)
+	now := clockSkew.now()
+	endTime := now.Add(-time.Duration(delay) * time.Second)
+	startTime := now.Add(-(time.Duration(length) + time.Duration(delay)) * time.Second)
+	if metric.StartOffset != 0 {
+		startTime, endTime = offsetTimeWindow(metric.StartOffset, metric.EndOffset, period, now)
+	}
+	warnUnretainedPeriod(aws.StringValue(namespace), metric.Name, period, startTime)
 
 	var statistics []*string
 	var extendedStatistics []*string
@@ -150,14 +163,16 @@ func findGetMetricDataById(getMetricDatas []cloudwatchData, value string) (cloud
 	return g, fmt.Errorf("Metric with id %s not found", value)
 }
 
-func createGetMetricDataInput(getMetricData []cloudwatchData, namespace *string, length int, delay int, now time.Time, floatingTimeWindow bool) (output *cloudwatch.GetMetricDataInput) {
+func createGetMetricDataInput(getMetricData []cloudwatchData, length int, delay int, now time.Time, floatingTimeWindow bool) (output *cloudwatch.GetMetricDataInput) {
 	var metricsDataQuery []*cloudwatch.MetricDataQuery
 	for _, data := range getMetricData {
+		// data.Namespace holds the job type, which can be either the alias or the namespace of the service
+		namespace := SupportedServices.GetService(*data.Namespace).Namespace
 		metricStat := &cloudwatch.MetricStat{
 			Metric: &cloudwatch.Metric{
 				Dimensions: data.Dimensions,
 				MetricName: data.Metric,
-				Namespace:  namespace,
+				Namespace:  &namespace,
 			},
 			Period: &data.Period,
 			Stat:   &data.Statistics[0],
@@ -176,9 +191,9 @@ func createGetMetricDataInput(getMetricData []cloudwatchData, namespace *string,
 	if now.IsZero() {
 		//This is first run
 		if floatingTimeWindow {
-			now = time.Now()
+			now = clockSkew.now()
 		} else {
-			now = time.Now().Round(5 * time.Minute)
+			now = clockSkew.now().Round(timeWindowRounding(getMetricData))
 		}
 		endTime = now.Add(-time.Duration(delay) * time.Second)
 		startTime = now.Add(-(time.Duration(length) + time.Duration(delay)) * time.Second)
@@ -187,6 +202,15 @@ func createGetMetricDataInput(getMetricData []cloudwatchData, namespace *string,
 		startTime = now
 	}
 
+	// Queries with offsets are batched by their offsets and period
+	if data := getMetricData[0]; data.StartOffset != 0 {
+		startTime, endTime = offsetTimeWindow(data.StartOffset, data.EndOffset, data.Period, clockSkew.now())
+	}
+
+	for _, data := range getMetricData {
+		warnUnretainedPeriod(aws.StringValue(data.Namespace), *data.Metric, data.Period, startTime)
+	}
+
 	dataPointOrder := "TimestampDescending"
 	output = &cloudwatch.GetMetricDataInput{
 		EndTime:           &endTime,
@@ -198,6 +222,64 @@ func createGetMetricDataInput(getMetricData []cloudwatchData, namespace *string,
 	return output
 }
 
+// offsetTimeWindow returns the window from startOffset to endOffset before now, with now truncated to the period so the
+// window covers whole periods, e.g. yesterday with a startOffset of 24h, no endOffset and a period of a day
+func offsetTimeWindow(startOffset, endOffset time.Duration, period int64, now time.Time) (startTime, endTime time.Time) {
+	if period > 0 {
+		now = now.Truncate(time.Duration(period) * time.Second)
+	}
+	return now.Add(-startOffset), now.Add(-endOffset)
+}
+
+// unretainedPeriodKey identifies a metric queried with a period CloudWatch doesn't retain
+type unretainedPeriodKey struct {
+	namespace string
+	metric    string
+	period    int64
+}
+
+// unretainedPeriodWarnings remembers the metrics already warned about, so every scrape doesn't repeat the warnings
+var unretainedPeriodWarnings sync.Map
+
+// ResetUnretainedPeriodWarnings forgets the metrics already warned about, after a config reload they are warned about again
+func ResetUnretainedPeriodWarnings() {
+	unretainedPeriodWarnings.Range(func(key, _ interface{}) bool {
+		unretainedPeriodWarnings.Delete(key)
+		return true
+	})
+}
+
+// warnUnretainedPeriod warns once when CloudWatch has rolled the datapoints since startTime up to periods the queried
+// period isn't a multiple of, so the query returns no datapoints
+func warnUnretainedPeriod(namespace, metric string, period int64, startTime time.Time) bool {
+	retained := int64(retainedPeriod(int(clockSkew.now().Sub(startTime).Seconds())))
+	if retained != 0 && period%retained == 0 {
+		return false
+	}
+	if _, warned := unretainedPeriodWarnings.LoadOrStore(unretainedPeriodKey{namespace: namespace, metric: metric, period: period}, true); warned {
+		return false
+	}
+	if retained == 0 {
+		log.Warningf("Querying %s since %s, CloudWatch retains no datapoints from then on", metric, startTime.Format(time.RFC3339))
+		return true
+	}
+	log.Warningf("Querying %s with period %d since %s, CloudWatch only retains datapoints of multiples of %d seconds from then on. Set adjustPeriod to round the period up",
+		metric, period, startTime.Format(time.RFC3339), retained)
+	return true
+}
+
+// timeWindowRounding returns the interval the time window is rounded to, 5 minutes unless high resolution periods are
+// queried which would lose their latest datapoints to it
+func timeWindowRounding(getMetricData []cloudwatchData) time.Duration {
+	rounding := 5 * time.Minute
+	for _, data := range getMetricData {
+		if period := time.Duration(data.Period) * time.Second; period > 0 && period < time.Minute && period < rounding {
+			rounding = period
+		}
+	}
+	return rounding
+}
+
 func createListMetricsInput(dimensions []*cloudwatch.Dimension, namespace *string, metricsName *string) (output *cloudwatch.ListMetricsInput) {
 	var dimensionsFilter []*cloudwatch.DimensionFilter
 
@@ -222,7 +304,7 @@ func dimensionsToCliString(dimensions []*cloudwatch.Dimension) (output string) {
 	return output
 }
 
-func (iface cloudwatchInterface) get(filter *cloudwatch.GetMetricStatisticsInput) []*cloudwatch.Datapoint {
+func (iface cloudwatchInterface) get(filter *cloudwatch.GetMetricStatisticsInput) ([]*cloudwatch.Datapoint, error) {
 	c := iface.client
 
 	log.Debug(filter)
@@ -235,14 +317,13 @@ func (iface cloudwatchInterface) get(filter *cloudwatch.GetMetricStatisticsInput
 	cloudwatchGetMetricStatisticsAPICounter.Inc()
 
 	if err != nil {
-		log.Warningf("Unable to get metric statistics due to %v", err)
-		return nil
+		return nil, fmt.Errorf("Unable to get metric statistics due to %w", err)
 	}
 
-	return resp.Datapoints
+	return resp.Datapoints, nil
 }
 
-func (iface cloudwatchInterface) getMetricData(filter *cloudwatch.GetMetricDataInput) *cloudwatch.GetMetricDataOutput {
+func (iface cloudwatchInterface) getMetricData(filter *cloudwatch.GetMetricDataInput) (*cloudwatch.GetMetricDataOutput, error) {
 	c := iface.client
 
 	var resp cloudwatch.GetMetricDataOutput
@@ -265,10 +346,9 @@ func (iface cloudwatchInterface) getMetricData(filter *cloudwatch.GetMetricDataI
 	}
 
 	if err != nil {
-		log.Warningf("Unable to get metric data due to %v", err)
-		return nil
+		return nil, fmt.Errorf("Unable to get metric data due to %w", err)
 	}
-	return &resp
+	return &resp, nil
 }
 
 func createStaticDimensions(dimensions []Dimension) (output []*cloudwatch.Dimension) {
@@ -283,20 +363,21 @@ func createStaticDimensions(dimensions []Dimension) (output []*cloudwatch.Dimens
 	return output
 }
 
-func getFullMetricsList(namespace string, metric *Metric, clientCloudwatch cloudwatchInterface) (resp *cloudwatch.ListMetricsOutput) {
+func getFullMetricsList(namespace string, metric *Metric, clientCloudwatch cloudwatchInterface) (resp *cloudwatch.ListMetricsOutput, requests int, err error) {
 	c := clientCloudwatch.client
 	filter := createListMetricsInput(nil, &namespace, &metric.Name)
 	var res cloudwatch.ListMetricsOutput
-	err := c.ListMetricsPages(filter,
+	err = c.ListMetricsPages(filter,
 		func(page *cloudwatch.ListMetricsOutput, lastPage bool) bool {
+			requests++
 			res.Metrics = append(res.Metrics, page.Metrics...)
 			return !lastPage
 		})
 	cloudwatchAPICounter.Inc()
 	if err != nil {
-		log.Fatalf("Unable to list metrics due to %v", err)
+		return nil, requests, fmt.Errorf("Unable to list metrics due to %w", err)
 	}
-	return &res
+	return &res, requests, nil
 }
 
 func getFilteredMetricDatas(region string, accountId *string, namespace string, customTags []Tag, tagsOnMetrics exportedTagsOnMetrics, dimensionRegexps []*string, resources []*tagsData, metricsList []*cloudwatch.Metric, m *Metric) (getMetricsData []cloudwatchData) {
@@ -307,7 +388,7 @@ func getFilteredMetricDatas(region string, accountId *string, namespace string,
 		names := dimensionRegexp.SubexpNames()
 		for i, dimensionName := range names {
 			if i != 0 {
-				names[i] = strings.ReplaceAll(dimensionName, "_", " ")
+				names[i] = subexpToDimensionName(dimensionName)
 				if _, ok := dimensionsFilter[names[i]]; !ok {
 					dimensionsFilter[names[i]] = make(filterValues)
 				}
@@ -325,6 +406,9 @@ func getFilteredMetricDatas(region string, accountId *string, namespace string,
 		}
 	}
 	for _, cwMetric := range metricsList {
+		if len(m.DimensionNameRequirements) > 0 && !metricDimensionsMatchNames(cwMetric, m.DimensionNameRequirements) {
+			continue
+		}
 		skip := false
 		r := &tagsData{
 			ID:        aws.String("global"),
@@ -358,6 +442,10 @@ func getFilteredMetricDatas(region string, accountId *string, namespace string,
 					Region:                 &region,
 					AccountId:              accountId,
 					Period:                 int64(m.Period),
+					PercentilesAsQuantiles: m.PercentilesAsQuantiles,
+					ComputeAverage:         m.ComputeAverage,
+					StartOffset:            m.StartOffset,
+					EndOffset:              m.EndOffset,
 				})
 			}
 		}
@@ -365,6 +453,28 @@ func getFilteredMetricDatas(region string, accountId *string, namespace string,
 	return getMetricsData
 }
 
+// subexpToDimensionName maps a regexp group name to a dimension name. Group names can't contain
+// spaces, so "_" stands for a space (e.g. "Cluster Name") and "__" for a literal underscore (e.g. "function_name").
+func subexpToDimensionName(name string) string {
+	parts := strings.Split(name, "__")
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(part, "_", " ")
+	}
+	return strings.Join(parts, "_")
+}
+
+func metricDimensionsMatchNames(metric *cloudwatch.Metric, dimensionNameRequirements []string) bool {
+	if len(dimensionNameRequirements) != len(metric.Dimensions) {
+		return false
+	}
+	for _, dimension := range metric.Dimensions {
+		if !stringInSlice(*dimension.Name, dimensionNameRequirements) {
+			return false
+		}
+	}
+	return true
+}
+
 func createPrometheusLabels(cwd *cloudwatchData, labelsSnakeCase bool) map[string]string {
 	labels := make(map[string]string)
 	labels["name"] = *cwd.ID
@@ -382,19 +492,42 @@ func createPrometheusLabels(cwd *cloudwatchData, labelsSnakeCase bool) map[strin
 	for _, tag := range cwd.Tags {
 		labels["tag_"+promStringTag(tag.Key, labelsSnakeCase)] = tag.Value
 	}
+	for _, tag := range cwd.AccountTags {
+		labels["account_tag_"+promStringTag(tag.Key, labelsSnakeCase)] = tag.Value
+	}
+	for name, value := range cwd.RoleLabels {
+		labels[name] = value
+	}
 
 	return labels
 }
 
-func recordLabelsForMetric(metricName string, promLabels map[string]string) {
+// percentileQuantile returns the quantile of a percentile statistic, e.g. 0.999 for p99.9
+func percentileQuantile(statistic string) (string, bool) {
+	if !strings.HasPrefix(statistic, "p") {
+		return "", false
+	}
+	percentile := strings.TrimPrefix(statistic, "p")
+	value, err := strconv.ParseFloat(percentile, 64)
+	if err != nil || value < 0 || value > 100 {
+		return "", false
+	}
+	// Formatted with the precision of the percentile to not export float errors like 0.9990000000000001
+	decimals := 0
+	if i := strings.Index(percentile, "."); i >= 0 {
+		decimals = len(percentile) - i - 1
+	}
+	quantile := strconv.FormatFloat(value/100, 'f', decimals+2, 64)
+	return strings.TrimRight(strings.TrimRight(quantile, "0"), "."), true
+}
+
+func recordLabelsForMetric(metricName string, labelNames []string) {
 	var workingLabelsCopy []string
 	if _, ok := labelMap[metricName]; ok {
 		workingLabelsCopy = append(workingLabelsCopy, labelMap[metricName]...)
 	}
 
-	for k := range promLabels {
-		workingLabelsCopy = append(workingLabelsCopy, k)
-	}
+	workingLabelsCopy = append(workingLabelsCopy, labelNames...)
 	sort.Strings(workingLabelsCopy)
 	j := 0
 	for i := 1; i < len(workingLabelsCopy); i++ {
@@ -411,19 +544,18 @@ func ensureLabelConsistencyForMetrics(metrics []*PrometheusMetric) []*Prometheus
 	var updatedMetrics []*PrometheusMetric
 
 	for _, prometheusMetric := range metrics {
-		metricName := prometheusMetric.name
-		metricLabels := prometheusMetric.labels
-
-		consistentMetricLabels := make(map[string]string)
-
-		for _, recordedLabel := range labelMap[*metricName] {
-			if value, ok := metricLabels[recordedLabel]; ok {
-				consistentMetricLabels[recordedLabel] = value
-			} else {
-				consistentMetricLabels[recordedLabel] = ""
+		// The recorded labels are sorted like the labels of the metric and include them, the metrics of a name share them
+		recordedLabels := labelMap[*prometheusMetric.name]
+		consistentLabelValues := make([]string, len(recordedLabels))
+		j := 0
+		for i, recordedLabel := range recordedLabels {
+			if j < len(prometheusMetric.labelNames) && prometheusMetric.labelNames[j] == recordedLabel {
+				consistentLabelValues[i] = prometheusMetric.labelValues[j]
+				j++
 			}
 		}
-		prometheusMetric.labels = consistentMetricLabels
+		prometheusMetric.labelNames = recordedLabels
+		prometheusMetric.labelValues = consistentLabelValues
 		updatedMetrics = append(updatedMetrics, prometheusMetric)
 	}
 	return updatedMetrics
@@ -492,8 +624,21 @@ func getDatapoint(cwd *cloudwatchData, statistic string) (*float64, time.Time) {
 	return nil, time.Time{}
 }
 
+// computedAverage collects the Sum and SampleCount of a series to export their quotient
+type computedAverage struct {
+	name             string
+	labelNames       []string
+	labelValues      []string
+	sum              *float64
+	sampleCount      *float64
+	timestamp        time.Time
+	includeTimestamp bool
+}
+
 func migrateCloudwatchToPrometheus(cwd []*cloudwatchData, labelsSnakeCase bool) []*PrometheusMetric {
 	output := make([]*PrometheusMetric, 0)
+	var averages []*computedAverage
+	averageIndex := newMetricIndex(0)
 
 	for _, c := range cwd {
 		for _, statistic := range c.Statistics {
@@ -515,22 +660,76 @@ func migrateCloudwatchToPrometheus(cwd []*cloudwatchData, labelsSnakeCase bool)
 			if !strings.HasPrefix(promNs, "aws") {
 				promNs = "aws_" + promNs
 			}
-			name := promString(promNs) + "_" + strings.ToLower(promString(*c.Metric)) + "_" + strings.ToLower(promString(statistic))
+			name := promString(promNs) + "_" + strings.ToLower(promString(*c.Metric))
+			quantile, isQuantile := percentileQuantile(statistic)
+			isQuantile = isQuantile && c.PercentilesAsQuantiles
+			if !isQuantile {
+				name += "_" + strings.ToLower(promString(statistic))
+			}
 			if exportedDatapoint != nil {
 
 				promLabels := createPrometheusLabels(c, labelsSnakeCase)
-				recordLabelsForMetric(name, promLabels)
+				if isQuantile {
+					promLabels["quantile"] = quantile
+				}
 				p := PrometheusMetric{
 					name:             &name,
-					labels:           promLabels,
 					value:            exportedDatapoint,
 					timestamp:        timestamp,
 					includeTimestamp: includeTimestamp,
 				}
+				p.setLabels(promLabels)
+				recordLabelsForMetric(name, p.labelNames)
 				output = append(output, &p)
+
+				if c.ComputeAverage && (statistic == "Sum" || statistic == "SampleCount") {
+					averageName := promString(promNs) + "_" + strings.ToLower(promString(*c.Metric)) + "_computed_average"
+					hash := hashStrings(hashStrings(hashString(fnvOffset, averageName), p.labelNames), p.labelValues)
+					i := averageIndex.find(hash, func(i int) bool {
+						return averages[i].name == averageName && equalStrings(averages[i].labelNames, p.labelNames) && equalStrings(averages[i].labelValues, p.labelValues)
+					})
+					var average *computedAverage
+					if i >= 0 {
+						average = averages[i]
+					} else {
+						average = &computedAverage{name: averageName, labelNames: p.labelNames, labelValues: p.labelValues}
+						averages = append(averages, average)
+						averageIndex.add(hash)
+					}
+					if statistic == "Sum" {
+						average.sum = exportedDatapoint
+					} else {
+						average.sampleCount = exportedDatapoint
+					}
+					average.timestamp = timestamp
+					average.includeTimestamp = includeTimestamp
+				}
 			}
 		}
 	}
 
+	for _, average := range averages {
+		if average := average.metric(); average != nil {
+			output = append(output, average)
+		}
+	}
+
 	return output
 }
+
+// metric returns the Sum divided by the SampleCount, or nil without samples
+func (a *computedAverage) metric() *PrometheusMetric {
+	if a.sum == nil || a.sampleCount == nil || math.IsNaN(*a.sum) || math.IsNaN(*a.sampleCount) || *a.sampleCount == 0 {
+		return nil
+	}
+	value := *a.sum / *a.sampleCount
+	recordLabelsForMetric(a.name, a.labelNames)
+	return &PrometheusMetric{
+		name:             &a.name,
+		labelNames:       a.labelNames,
+		labelValues:      a.labelValues,
+		value:            &value,
+		timestamp:        a.timestamp,
+		includeTimestamp: a.includeTimestamp,
+	}
+}
@@ -240,10 +240,3366 @@ func Test_getFilteredMetricDatas(t *testing.T) {
 				},
 			},
 		},
+		{
+			"athena",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "athena",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"athena": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("athena").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:athena:us-east-1:123123123123:workgroup/primary"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("athena"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("TotalExecutionTime"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("WorkGroup"),
+								Value: aws.String("primary"),
+							},
+						},
+						Namespace: aws.String("AWS/Athena"),
+					},
+				},
+				m: &Metric{
+					Name: "TotalExecutionTime",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("WorkGroup"),
+							Value: aws.String("primary"),
+						},
+					},
+					ID:        aws.String("arn:aws:athena:us-east-1:123123123123:workgroup/primary"),
+					Metric:    aws.String("TotalExecutionTime"),
+					Namespace: aws.String("athena"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"ses",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "ses",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"ses": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("ses").DimensionRegexps,
+				resources:        nil,
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("Reputation.BounceRate"),
+						Dimensions: []*cloudwatch.Dimension{},
+						Namespace:  aws.String("AWS/SES"),
+					},
+				},
+				m: &Metric{
+					Name: "Reputation.BounceRate",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions:             []*cloudwatch.Dimension{},
+					ID:                     aws.String("global"),
+					Metric:                 aws.String("Reputation.BounceRate"),
+					Namespace:              aws.String("ses"),
+					NilToZero:              aws.Bool(false),
+					Period:                 60,
+					Region:                 aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"cognito-idp",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "cognito-idp",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"cognito-idp": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("cognito-idp").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:cognito-idp:us-east-1:123123123123:userpool/us-east-1_aBcDeFgHi"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("cognito-idp"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("SignInSuccesses"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("UserPool"),
+								Value: aws.String("us-east-1_aBcDeFgHi"),
+							},
+							{
+								Name:  aws.String("UserPoolClient"),
+								Value: aws.String("1example23456789"),
+							},
+						},
+						Namespace: aws.String("AWS/Cognito"),
+					},
+				},
+				m: &Metric{
+					Name: "SignInSuccesses",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("UserPool"),
+							Value: aws.String("us-east-1_aBcDeFgHi"),
+						},
+						{
+							Name:  aws.String("UserPoolClient"),
+							Value: aws.String("1example23456789"),
+						},
+					},
+					ID:        aws.String("arn:aws:cognito-idp:us-east-1:123123123123:userpool/us-east-1_aBcDeFgHi"),
+					Metric:    aws.String("SignInSuccesses"),
+					Namespace: aws.String("cognito-idp"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"dms",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "dms",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"dms": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("dms").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:dms:us-east-1:123123123123:task:ABCDEFGHIJKLMNOPQRSTUVWXYZ/my-instance"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("dms"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("CDCLatencySource"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("ReplicationInstanceIdentifier"),
+								Value: aws.String("my-instance"),
+							},
+							{
+								Name:  aws.String("ReplicationTaskIdentifier"),
+								Value: aws.String("ABCDEFGHIJKLMNOPQRSTUVWXYZ"),
+							},
+						},
+						Namespace: aws.String("AWS/DMS"),
+					},
+				},
+				m: &Metric{
+					Name: "CDCLatencySource",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("ReplicationInstanceIdentifier"),
+							Value: aws.String("my-instance"),
+						},
+						{
+							Name:  aws.String("ReplicationTaskIdentifier"),
+							Value: aws.String("ABCDEFGHIJKLMNOPQRSTUVWXYZ"),
+						},
+					},
+					ID:        aws.String("arn:aws:dms:us-east-1:123123123123:task:ABCDEFGHIJKLMNOPQRSTUVWXYZ/my-instance"),
+					Metric:    aws.String("CDCLatencySource"),
+					Namespace: aws.String("dms"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"dx",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "dx",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"dx": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("dx").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:directconnect:us-east-1:123123123123:dxcon/dxcon-fg5678gh"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("dx"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("ConnectionLightLevelTx"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("ConnectionId"),
+								Value: aws.String("dxcon-fg5678gh"),
+							},
+						},
+						Namespace: aws.String("AWS/DX"),
+					},
+				},
+				m: &Metric{
+					Name: "ConnectionLightLevelTx",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("ConnectionId"),
+							Value: aws.String("dxcon-fg5678gh"),
+						},
+					},
+					ID:        aws.String("arn:aws:directconnect:us-east-1:123123123123:dxcon/dxcon-fg5678gh"),
+					Metric:    aws.String("ConnectionLightLevelTx"),
+					Namespace: aws.String("dx"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"mq",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "mq",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"mq": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("mq").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:mq:us-east-1:123123123123:broker:rabbitmq-broker:b-1234a5b6-78cd-901e-2fgh-3i45j6k178l9"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("mq"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("MessageCount"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("Broker"),
+								Value: aws.String("rabbitmq-broker"),
+							},
+							{
+								Name:  aws.String("Queue"),
+								Value: aws.String("orders"),
+							},
+							{
+								Name:  aws.String("VirtualHost"),
+								Value: aws.String("/"),
+							},
+						},
+						Namespace: aws.String("AWS/AmazonMQ"),
+					},
+				},
+				m: &Metric{
+					Name: "MessageCount",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("Broker"),
+							Value: aws.String("rabbitmq-broker"),
+						},
+						{
+							Name:  aws.String("Queue"),
+							Value: aws.String("orders"),
+						},
+						{
+							Name:  aws.String("VirtualHost"),
+							Value: aws.String("/"),
+						},
+					},
+					ID:        aws.String("arn:aws:mq:us-east-1:123123123123:broker:rabbitmq-broker:b-1234a5b6-78cd-901e-2fgh-3i45j6k178l9"),
+					Metric:    aws.String("MessageCount"),
+					Namespace: aws.String("mq"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"neptune",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "neptune",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"neptune": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("neptune").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:rds:us-east-1:123123123123:cluster:neptune-cluster"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("neptune"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("GremlinRequestsPerSec"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("DBClusterIdentifier"),
+								Value: aws.String("neptune-cluster"),
+							},
+							{
+								Name:  aws.String("Role"),
+								Value: aws.String("WRITER"),
+							},
+						},
+						Namespace: aws.String("AWS/Neptune"),
+					},
+				},
+				m: &Metric{
+					Name: "GremlinRequestsPerSec",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("DBClusterIdentifier"),
+							Value: aws.String("neptune-cluster"),
+						},
+						{
+							Name:  aws.String("Role"),
+							Value: aws.String("WRITER"),
+						},
+					},
+					ID:        aws.String("arn:aws:rds:us-east-1:123123123123:cluster:neptune-cluster"),
+					Metric:    aws.String("GremlinRequestsPerSec"),
+					Namespace: aws.String("neptune"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"sagemaker",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "sagemaker",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"sagemaker": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("sagemaker").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:sagemaker:us-east-1:123123123123:endpoint/example-endpoint"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("sagemaker"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("Invocations"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("EndpointName"),
+								Value: aws.String("example-endpoint"),
+							},
+							{
+								Name:  aws.String("VariantName"),
+								Value: aws.String("AllTraffic"),
+							},
+						},
+						Namespace: aws.String("AWS/SageMaker"),
+					},
+				},
+				m: &Metric{
+					Name: "Invocations",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("EndpointName"),
+							Value: aws.String("example-endpoint"),
+						},
+						{
+							Name:  aws.String("VariantName"),
+							Value: aws.String("AllTraffic"),
+						},
+					},
+					ID:        aws.String("arn:aws:sagemaker:us-east-1:123123123123:endpoint/example-endpoint"),
+					Metric:    aws.String("Invocations"),
+					Namespace: aws.String("sagemaker"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"workspaces",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "workspaces",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"workspaces": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("workspaces").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:workspaces:us-east-1:123123123123:directory/d-1234567890"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("workspaces"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("Unhealthy"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("DirectoryId"),
+								Value: aws.String("d-1234567890"),
+							},
+						},
+						Namespace: aws.String("AWS/WorkSpaces"),
+					},
+				},
+				m: &Metric{
+					Name: "Unhealthy",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("DirectoryId"),
+							Value: aws.String("d-1234567890"),
+						},
+					},
+					ID:        aws.String("arn:aws:workspaces:us-east-1:123123123123:directory/d-1234567890"),
+					Metric:    aws.String("Unhealthy"),
+					Namespace: aws.String("workspaces"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"ga",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "ga",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"ga": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("ga").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:globalaccelerator::123123123123:accelerator/1234abcd-abcd-1234-abcd-1234abcdefgh"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("ga"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("NewFlowCount"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("Accelerator"),
+								Value: aws.String("1234abcd-abcd-1234-abcd-1234abcdefgh"),
+							},
+							{
+								Name:  aws.String("Listener"),
+								Value: aws.String("0123vxyz"),
+							},
+							{
+								Name:  aws.String("EndpointGroup"),
+								Value: aws.String("ab88888example"),
+							},
+						},
+						Namespace: aws.String("AWS/GlobalAccelerator"),
+					},
+				},
+				m: &Metric{
+					Name: "NewFlowCount",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("Accelerator"),
+							Value: aws.String("1234abcd-abcd-1234-abcd-1234abcdefgh"),
+						},
+						{
+							Name:  aws.String("Listener"),
+							Value: aws.String("0123vxyz"),
+						},
+						{
+							Name:  aws.String("EndpointGroup"),
+							Value: aws.String("ab88888example"),
+						},
+					},
+					ID:        aws.String("arn:aws:globalaccelerator::123123123123:accelerator/1234abcd-abcd-1234-abcd-1234abcdefgh"),
+					Metric:    aws.String("NewFlowCount"),
+					Namespace: aws.String("ga"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"storagegateway",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "storagegateway",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"storagegateway": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("storagegateway").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:storagegateway:us-east-1:123123123123:share/share-ABCD1234"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("storagegateway"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("CacheHitPercent"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("ShareId"),
+								Value: aws.String("share-ABCD1234"),
+							},
+						},
+						Namespace: aws.String("AWS/StorageGateway"),
+					},
+				},
+				m: &Metric{
+					Name: "CacheHitPercent",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("ShareId"),
+							Value: aws.String("share-ABCD1234"),
+						},
+					},
+					ID:        aws.String("arn:aws:storagegateway:us-east-1:123123123123:share/share-ABCD1234"),
+					Metric:    aws.String("CacheHitPercent"),
+					Namespace: aws.String("storagegateway"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"mediaconvert",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "mediaconvert",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"mediaconvert": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("mediaconvert").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:mediaconvert:us-east-1:123123123123:queues/Default"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("mediaconvert"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("JobsCompletedCount"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("Queue"),
+								Value: aws.String("arn:aws:mediaconvert:us-east-1:123123123123:queues/Default"),
+							},
+						},
+						Namespace: aws.String("AWS/MediaConvert"),
+					},
+				},
+				m: &Metric{
+					Name: "JobsCompletedCount",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("Queue"),
+							Value: aws.String("arn:aws:mediaconvert:us-east-1:123123123123:queues/Default"),
+						},
+					},
+					ID:        aws.String("arn:aws:mediaconvert:us-east-1:123123123123:queues/Default"),
+					Metric:    aws.String("JobsCompletedCount"),
+					Namespace: aws.String("mediaconvert"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"mediapackage",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "mediapackage",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"mediapackage": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("mediapackage").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:mediapackage:us-east-1:123123123123:origin_endpoints/1234567890abcdef1234567890abcdef/live-channel/live-hls"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("mediapackage"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("EgressRequestCount"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("Channel"),
+								Value: aws.String("live-channel"),
+							},
+							{
+								Name:  aws.String("OriginEndpoint"),
+								Value: aws.String("live-hls"),
+							},
+							{
+								Name:  aws.String("StatusCodeRange"),
+								Value: aws.String("2xx"),
+							},
+						},
+						Namespace: aws.String("AWS/MediaPackage"),
+					},
+				},
+				m: &Metric{
+					Name: "EgressRequestCount",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("Channel"),
+							Value: aws.String("live-channel"),
+						},
+						{
+							Name:  aws.String("OriginEndpoint"),
+							Value: aws.String("live-hls"),
+						},
+						{
+							Name:  aws.String("StatusCodeRange"),
+							Value: aws.String("2xx"),
+						},
+					},
+					ID:        aws.String("arn:aws:mediapackage:us-east-1:123123123123:origin_endpoints/1234567890abcdef1234567890abcdef/live-channel/live-hls"),
+					Metric:    aws.String("EgressRequestCount"),
+					Namespace: aws.String("mediapackage"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"nfw",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "nfw",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"nfw": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("nfw").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:network-firewall:us-east-1:123123123123:firewall/inspection-firewall"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("nfw"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("DroppedPackets"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("FirewallName"),
+								Value: aws.String("inspection-firewall"),
+							},
+							{
+								Name:  aws.String("AvailabilityZone"),
+								Value: aws.String("us-east-1a"),
+							},
+							{
+								Name:  aws.String("Engine"),
+								Value: aws.String("Stateless"),
+							},
+						},
+						Namespace: aws.String("AWS/NetworkFirewall"),
+					},
+				},
+				m: &Metric{
+					Name: "DroppedPackets",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("FirewallName"),
+							Value: aws.String("inspection-firewall"),
+						},
+						{
+							Name:  aws.String("AvailabilityZone"),
+							Value: aws.String("us-east-1a"),
+						},
+						{
+							Name:  aws.String("Engine"),
+							Value: aws.String("Stateless"),
+						},
+					},
+					ID:        aws.String("arn:aws:network-firewall:us-east-1:123123123123:firewall/inspection-firewall"),
+					Metric:    aws.String("DroppedPackets"),
+					Namespace: aws.String("nfw"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"memorydb",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "memorydb",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"memorydb": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("memorydb").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:memorydb:us-east-1:123123123123:cluster/sessions"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("memorydb"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("EngineCPUUtilization"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("ClusterName"),
+								Value: aws.String("sessions"),
+							},
+							{
+								Name:  aws.String("NodeName"),
+								Value: aws.String("sessions-0001-001"),
+							},
+						},
+						Namespace: aws.String("AWS/MemoryDB"),
+					},
+				},
+				m: &Metric{
+					Name: "EngineCPUUtilization",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("ClusterName"),
+							Value: aws.String("sessions"),
+						},
+						{
+							Name:  aws.String("NodeName"),
+							Value: aws.String("sessions-0001-001"),
+						},
+					},
+					ID:        aws.String("arn:aws:memorydb:us-east-1:123123123123:cluster/sessions"),
+					Metric:    aws.String("EngineCPUUtilization"),
+					Namespace: aws.String("memorydb"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"cassandra",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "cassandra",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"cassandra": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("cassandra").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:cassandra:us-east-1:123123123123:/keyspace/orders/table/line_items"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("cassandra"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("ConsumedReadCapacityUnits"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("Keyspace"),
+								Value: aws.String("orders"),
+							},
+							{
+								Name:  aws.String("Operation"),
+								Value: aws.String("SELECT"),
+							},
+							{
+								Name:  aws.String("TableName"),
+								Value: aws.String("line_items"),
+							},
+						},
+						Namespace: aws.String("AWS/Cassandra"),
+					},
+				},
+				m: &Metric{
+					Name: "ConsumedReadCapacityUnits",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("Keyspace"),
+							Value: aws.String("orders"),
+						},
+						{
+							Name:  aws.String("Operation"),
+							Value: aws.String("SELECT"),
+						},
+						{
+							Name:  aws.String("TableName"),
+							Value: aws.String("line_items"),
+						},
+					},
+					ID:        aws.String("arn:aws:cassandra:us-east-1:123123123123:/keyspace/orders/table/line_items"),
+					Metric:    aws.String("ConsumedReadCapacityUnits"),
+					Namespace: aws.String("cassandra"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"elasticbeanstalk",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "elasticbeanstalk",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"elasticbeanstalk": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("elasticbeanstalk").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:elasticbeanstalk:us-east-1:123123123123:environment/shop/shop-prod"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("elasticbeanstalk"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("ApplicationRequests5xx"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("EnvironmentName"),
+								Value: aws.String("shop-prod"),
+							},
+						},
+						Namespace: aws.String("AWS/ElasticBeanstalk"),
+					},
+				},
+				m: &Metric{
+					Name: "ApplicationRequests5xx",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("EnvironmentName"),
+							Value: aws.String("shop-prod"),
+						},
+					},
+					ID:        aws.String("arn:aws:elasticbeanstalk:us-east-1:123123123123:environment/shop/shop-prod"),
+					Metric:    aws.String("ApplicationRequests5xx"),
+					Namespace: aws.String("elasticbeanstalk"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"iot",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "iot",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"iot": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("iot").DimensionRegexps,
+				resources:        nil,
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("PublishIn.Success"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("Protocol"),
+								Value: aws.String("MQTT"),
+							},
+						},
+						Namespace: aws.String("AWS/IoT"),
+					},
+				},
+				m: &Metric{
+					Name: "PublishIn.Success",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("Protocol"),
+							Value: aws.String("MQTT"),
+						},
+					},
+					ID:        aws.String("global"),
+					Metric:    aws.String("PublishIn.Success"),
+					Namespace: aws.String("iot"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"kinesisvideo",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "kinesisvideo",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"kinesisvideo": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("kinesisvideo").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:kinesisvideo:us-east-1:123123123123:stream/front-door/1234567890123"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("kinesisvideo"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("PutMedia.Success"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("StreamName"),
+								Value: aws.String("front-door"),
+							},
+						},
+						Namespace: aws.String("AWS/KinesisVideo"),
+					},
+				},
+				m: &Metric{
+					Name: "PutMedia.Success",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("StreamName"),
+							Value: aws.String("front-door"),
+						},
+					},
+					ID:        aws.String("arn:aws:kinesisvideo:us-east-1:123123123123:stream/front-door/1234567890123"),
+					Metric:    aws.String("PutMedia.Success"),
+					Namespace: aws.String("kinesisvideo"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"route53",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "route53",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"route53": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("route53").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:route53:::hostedzone/Z1D633PJN98FT9"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("route53"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("DNSQueries"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("HostedZoneId"),
+								Value: aws.String("Z1D633PJN98FT9"),
+							},
+						},
+						Namespace: aws.String("AWS/Route53"),
+					},
+				},
+				m: &Metric{
+					Name: "DNSQueries",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("HostedZoneId"),
+							Value: aws.String("Z1D633PJN98FT9"),
+						},
+					},
+					ID:        aws.String("arn:aws:route53:::hostedzone/Z1D633PJN98FT9"),
+					Metric:    aws.String("DNSQueries"),
+					Namespace: aws.String("route53"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"r53r",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "r53r",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"r53r": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("r53r").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:route53resolver:us-east-1:123123123123:resolver-endpoint/rslvr-in-0123456789abcdef0"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("r53r"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("EndpointHealthyENICount"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("EndpointId"),
+								Value: aws.String("rslvr-in-0123456789abcdef0"),
+							},
+						},
+						Namespace: aws.String("AWS/Route53Resolver"),
+					},
+				},
+				m: &Metric{
+					Name: "EndpointHealthyENICount",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("EndpointId"),
+							Value: aws.String("rslvr-in-0123456789abcdef0"),
+						},
+					},
+					ID:        aws.String("arn:aws:route53resolver:us-east-1:123123123123:resolver-endpoint/rslvr-in-0123456789abcdef0"),
+					Metric:    aws.String("EndpointHealthyENICount"),
+					Namespace: aws.String("r53r"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"logs",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "logs",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"logs": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("logs").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:logs:us-east-1:123123123123:log-group:/aws/lambda/checkout"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("logs"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("IncomingBytes"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("LogGroupName"),
+								Value: aws.String("/aws/lambda/checkout"),
+							},
+						},
+						Namespace: aws.String("AWS/Logs"),
+					},
+				},
+				m: &Metric{
+					Name: "IncomingBytes",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("LogGroupName"),
+							Value: aws.String("/aws/lambda/checkout"),
+						},
+					},
+					ID:        aws.String("arn:aws:logs:us-east-1:123123123123:log-group:/aws/lambda/checkout"),
+					Metric:    aws.String("IncomingBytes"),
+					Namespace: aws.String("logs"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"eventbridge",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "eventbridge",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"eventbridge": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("eventbridge").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:events:us-east-1:123123123123:rule/orders/forward-to-sqs"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("eventbridge"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("FailedInvocations"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("RuleName"),
+								Value: aws.String("forward-to-sqs"),
+							},
+						},
+						Namespace: aws.String("AWS/Events"),
+					},
+				},
+				m: &Metric{
+					Name: "FailedInvocations",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("RuleName"),
+							Value: aws.String("forward-to-sqs"),
+						},
+					},
+					ID:        aws.String("arn:aws:events:us-east-1:123123123123:rule/orders/forward-to-sqs"),
+					Metric:    aws.String("FailedInvocations"),
+					Namespace: aws.String("eventbridge"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"dax",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "dax",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"dax": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("dax").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:dax:us-east-1:123123123123:cache/sessions-dax"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("dax"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("ItemCacheHits"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("ClusterId"),
+								Value: aws.String("sessions-dax"),
+							},
+							{
+								Name:  aws.String("NodeId"),
+								Value: aws.String("sessions-dax-a"),
+							},
+						},
+						Namespace: aws.String("AWS/DAX"),
+					},
+				},
+				m: &Metric{
+					Name: "ItemCacheHits",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("ClusterId"),
+							Value: aws.String("sessions-dax"),
+						},
+						{
+							Name:  aws.String("NodeId"),
+							Value: aws.String("sessions-dax-a"),
+						},
+					},
+					ID:        aws.String("arn:aws:dax:us-east-1:123123123123:cache/sessions-dax"),
+					Metric:    aws.String("ItemCacheHits"),
+					Namespace: aws.String("dax"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"acm",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "acm",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"acm": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("acm").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:acm:us-east-1:123123123123:certificate/12345678-1234-1234-1234-123456789012"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("acm"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("DaysToExpiry"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("CertificateArn"),
+								Value: aws.String("arn:aws:acm:us-east-1:123123123123:certificate/12345678-1234-1234-1234-123456789012"),
+							},
+						},
+						Namespace: aws.String("AWS/CertificateManager"),
+					},
+				},
+				m: &Metric{
+					Name: "DaysToExpiry",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("CertificateArn"),
+							Value: aws.String("arn:aws:acm:us-east-1:123123123123:certificate/12345678-1234-1234-1234-123456789012"),
+						},
+					},
+					ID:        aws.String("arn:aws:acm:us-east-1:123123123123:certificate/12345678-1234-1234-1234-123456789012"),
+					Metric:    aws.String("DaysToExpiry"),
+					Namespace: aws.String("acm"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"usage",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "usage",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"usage": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("usage").DimensionRegexps,
+				resources:        nil,
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("CallCount"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("Class"),
+								Value: aws.String("None"),
+							},
+							{
+								Name:  aws.String("Resource"),
+								Value: aws.String("GetMetricData"),
+							},
+							{
+								Name:  aws.String("Service"),
+								Value: aws.String("CloudWatch"),
+							},
+							{
+								Name:  aws.String("Type"),
+								Value: aws.String("API"),
+							},
+						},
+						Namespace: aws.String("AWS/Usage"),
+					},
+				},
+				m: &Metric{
+					Name: "CallCount",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("Class"),
+							Value: aws.String("None"),
+						},
+						{
+							Name:  aws.String("Resource"),
+							Value: aws.String("GetMetricData"),
+						},
+						{
+							Name:  aws.String("Service"),
+							Value: aws.String("CloudWatch"),
+						},
+						{
+							Name:  aws.String("Type"),
+							Value: aws.String("API"),
+						},
+					},
+					ID:        aws.String("global"),
+					Metric:    aws.String("CallCount"),
+					Namespace: aws.String("usage"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"clientvpn",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "clientvpn",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"clientvpn": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("clientvpn").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:ec2:us-east-1:123123123123:client-vpn-endpoint/cvpn-endpoint-0123456abcd123456"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("clientvpn"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("ActiveConnectionsCount"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("Endpoint"),
+								Value: aws.String("cvpn-endpoint-0123456abcd123456"),
+							},
+						},
+						Namespace: aws.String("AWS/ClientVPN"),
+					},
+				},
+				m: &Metric{
+					Name: "ActiveConnectionsCount",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("Endpoint"),
+							Value: aws.String("cvpn-endpoint-0123456abcd123456"),
+						},
+					},
+					ID:        aws.String("arn:aws:ec2:us-east-1:123123123123:client-vpn-endpoint/cvpn-endpoint-0123456abcd123456"),
+					Metric:    aws.String("ActiveConnectionsCount"),
+					Namespace: aws.String("clientvpn"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"shield",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "shield",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"shield": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("shield").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:elasticloadbalancing:us-east-1:123123123123:loadbalancer/app/public/0123456789abcdef"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("shield"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("DDoSDetected"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("ResourceArn"),
+								Value: aws.String("arn:aws:elasticloadbalancing:us-east-1:123123123123:loadbalancer/app/public/0123456789abcdef"),
+							},
+						},
+						Namespace: aws.String("AWS/DDoSProtection"),
+					},
+				},
+				m: &Metric{
+					Name: "DDoSDetected",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("ResourceArn"),
+							Value: aws.String("arn:aws:elasticloadbalancing:us-east-1:123123123123:loadbalancer/app/public/0123456789abcdef"),
+						},
+					},
+					ID:        aws.String("arn:aws:elasticloadbalancing:us-east-1:123123123123:loadbalancer/app/public/0123456789abcdef"),
+					Metric:    aws.String("DDoSDetected"),
+					Namespace: aws.String("shield"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"lex",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "lex",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"lex": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("lex").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:lex:us-east-1:123123123123:bot:OrderFlowers"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("lex"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("RuntimeRequestCount"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("BotAlias"),
+								Value: aws.String("prod"),
+							},
+							{
+								Name:  aws.String("BotName"),
+								Value: aws.String("OrderFlowers"),
+							},
+							{
+								Name:  aws.String("BotVersion"),
+								Value: aws.String("3"),
+							},
+							{
+								Name:  aws.String("Operation"),
+								Value: aws.String("PostText"),
+							},
+							{
+								Name:  aws.String("Source"),
+								Value: aws.String("Client"),
+							},
+						},
+						Namespace: aws.String("AWS/Lex"),
+					},
+				},
+				m: &Metric{
+					Name: "RuntimeRequestCount",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("BotAlias"),
+							Value: aws.String("prod"),
+						},
+						{
+							Name:  aws.String("BotName"),
+							Value: aws.String("OrderFlowers"),
+						},
+						{
+							Name:  aws.String("BotVersion"),
+							Value: aws.String("3"),
+						},
+						{
+							Name:  aws.String("Operation"),
+							Value: aws.String("PostText"),
+						},
+						{
+							Name:  aws.String("Source"),
+							Value: aws.String("Client"),
+						},
+					},
+					ID:        aws.String("arn:aws:lex:us-east-1:123123123123:bot:OrderFlowers"),
+					Metric:    aws.String("RuntimeRequestCount"),
+					Namespace: aws.String("lex"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"connect",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "connect",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"connect": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("connect").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:connect:us-east-1:123123123123:instance/aaaaaaaa-bbbb-cccc-dddd-111111111111"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("connect"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("QueueSize"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("InstanceId"),
+								Value: aws.String("aaaaaaaa-bbbb-cccc-dddd-111111111111"),
+							},
+							{
+								Name:  aws.String("MetricGroup"),
+								Value: aws.String("Queue"),
+							},
+							{
+								Name:  aws.String("QueueName"),
+								Value: aws.String("BasicQueue"),
+							},
+						},
+						Namespace: aws.String("AWS/Connect"),
+					},
+				},
+				m: &Metric{
+					Name: "QueueSize",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("InstanceId"),
+							Value: aws.String("aaaaaaaa-bbbb-cccc-dddd-111111111111"),
+						},
+						{
+							Name:  aws.String("MetricGroup"),
+							Value: aws.String("Queue"),
+						},
+						{
+							Name:  aws.String("QueueName"),
+							Value: aws.String("BasicQueue"),
+						},
+					},
+					ID:        aws.String("arn:aws:connect:us-east-1:123123123123:instance/aaaaaaaa-bbbb-cccc-dddd-111111111111"),
+					Metric:    aws.String("QueueSize"),
+					Namespace: aws.String("connect"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"redshift-serverless",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "redshift-serverless",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"redshift-serverless": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("redshift-serverless").DimensionRegexps,
+				resources:        nil,
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("ComputeCapacity"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("Workgroup"),
+								Value: aws.String("analytics"),
+							},
+						},
+						Namespace: aws.String("AWS/Redshift-Serverless"),
+					},
+				},
+				m: &Metric{
+					Name: "ComputeCapacity",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("Workgroup"),
+							Value: aws.String("analytics"),
+						},
+					},
+					ID:        aws.String("global"),
+					Metric:    aws.String("ComputeCapacity"),
+					Namespace: aws.String("redshift-serverless"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"rds",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "rds",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"rds": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("rds").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:rds:us-east-1:123123123123:cluster:aurora-prod"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("rds"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("AuroraReplicaLag"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("DBClusterIdentifier"),
+								Value: aws.String("aurora-prod"),
+							},
+							{
+								Name:  aws.String("Role"),
+								Value: aws.String("READER"),
+							},
+						},
+						Namespace: aws.String("AWS/RDS"),
+					},
+				},
+				m: &Metric{
+					Name: "AuroraReplicaLag",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("DBClusterIdentifier"),
+							Value: aws.String("aurora-prod"),
+						},
+						{
+							Name:  aws.String("Role"),
+							Value: aws.String("READER"),
+						},
+					},
+					ID:        aws.String("arn:aws:rds:us-east-1:123123123123:cluster:aurora-prod"),
+					Metric:    aws.String("AuroraReplicaLag"),
+					Namespace: aws.String("rds"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"lambda-insights",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "lambda-insights",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"lambda-insights": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("lambda-insights").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:lambda:us-east-1:123123123123:function:checkout"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("lambda-insights"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("memory_utilization"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("function_name"),
+								Value: aws.String("checkout"),
+							},
+						},
+						Namespace: aws.String("LambdaInsights"),
+					},
+				},
+				m: &Metric{
+					Name: "memory_utilization",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("function_name"),
+							Value: aws.String("checkout"),
+						},
+					},
+					ID:        aws.String("arn:aws:lambda:us-east-1:123123123123:function:checkout"),
+					Metric:    aws.String("memory_utilization"),
+					Namespace: aws.String("lambda-insights"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"timestream",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "timestream",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"timestream": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("timestream").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:timestream:us-east-1:123123123123:database/iot/table/readings"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("timestream"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("SuccessfulRequestLatency"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("DatabaseName"),
+								Value: aws.String("iot"),
+							},
+							{
+								Name:  aws.String("Operation"),
+								Value: aws.String("WriteRecords"),
+							},
+							{
+								Name:  aws.String("TableName"),
+								Value: aws.String("readings"),
+							},
+						},
+						Namespace: aws.String("AWS/Timestream"),
+					},
+				},
+				m: &Metric{
+					Name: "SuccessfulRequestLatency",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("DatabaseName"),
+							Value: aws.String("iot"),
+						},
+						{
+							Name:  aws.String("Operation"),
+							Value: aws.String("WriteRecords"),
+						},
+						{
+							Name:  aws.String("TableName"),
+							Value: aws.String("readings"),
+						},
+					},
+					ID:        aws.String("arn:aws:timestream:us-east-1:123123123123:database/iot/table/readings"),
+					Metric:    aws.String("SuccessfulRequestLatency"),
+					Namespace: aws.String("timestream"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"mwaa",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "mwaa",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"mwaa": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("mwaa").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:airflow:us-east-1:123123123123:environment/etl"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("mwaa"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("SchedulerHeartbeat"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("Environment"),
+								Value: aws.String("etl"),
+							},
+							{
+								Name:  aws.String("Function"),
+								Value: aws.String("Scheduler"),
+							},
+						},
+						Namespace: aws.String("AmazonMWAA"),
+					},
+				},
+				m: &Metric{
+					Name: "SchedulerHeartbeat",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("Environment"),
+							Value: aws.String("etl"),
+						},
+						{
+							Name:  aws.String("Function"),
+							Value: aws.String("Scheduler"),
+						},
+					},
+					ID:        aws.String("arn:aws:airflow:us-east-1:123123123123:environment/etl"),
+					Metric:    aws.String("SchedulerHeartbeat"),
+					Namespace: aws.String("mwaa"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"datasync",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "datasync",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"datasync": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("datasync").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:datasync:us-east-1:123123123123:task/task-0123456789abcdef0"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("datasync"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("BytesTransferred"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("TaskId"),
+								Value: aws.String("task-0123456789abcdef0"),
+							},
+						},
+						Namespace: aws.String("AWS/DataSync"),
+					},
+				},
+				m: &Metric{
+					Name: "BytesTransferred",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("TaskId"),
+							Value: aws.String("task-0123456789abcdef0"),
+						},
+					},
+					ID:        aws.String("arn:aws:datasync:us-east-1:123123123123:task/task-0123456789abcdef0"),
+					Metric:    aws.String("BytesTransferred"),
+					Namespace: aws.String("datasync"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"vpc-endpoint",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "vpc-endpoint",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"vpc-endpoint": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("vpc-endpoint").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:ec2:us-east-1:123123123123:vpc-endpoint/vpce-0123456789abcdef0"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("vpc-endpoint"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("BytesProcessed"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("VPC Endpoint Id"),
+								Value: aws.String("vpce-0123456789abcdef0"),
+							},
+							{
+								Name:  aws.String("VPC Id"),
+								Value: aws.String("vpc-1234"),
+							},
+							{
+								Name:  aws.String("Endpoint Type"),
+								Value: aws.String("Interface"),
+							},
+							{
+								Name:  aws.String("Service Name"),
+								Value: aws.String("com.amazonaws.us-east-1.s3"),
+							},
+						},
+						Namespace: aws.String("AWS/PrivateLinkEndpoints"),
+					},
+				},
+				m: &Metric{
+					Name: "BytesProcessed",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("VPC Endpoint Id"),
+							Value: aws.String("vpce-0123456789abcdef0"),
+						},
+						{
+							Name:  aws.String("VPC Id"),
+							Value: aws.String("vpc-1234"),
+						},
+						{
+							Name:  aws.String("Endpoint Type"),
+							Value: aws.String("Interface"),
+						},
+						{
+							Name:  aws.String("Service Name"),
+							Value: aws.String("com.amazonaws.us-east-1.s3"),
+						},
+					},
+					ID:        aws.String("arn:aws:ec2:us-east-1:123123123123:vpc-endpoint/vpce-0123456789abcdef0"),
+					Metric:    aws.String("BytesProcessed"),
+					Namespace: aws.String("vpc-endpoint"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
+		{
+			"personalize",
+			args{
+				region:     "us-east-1",
+				accountId:  aws.String("123123123123"),
+				namespace:  "personalize",
+				customTags: nil,
+				tagsOnMetrics: map[string][]string{
+					"personalize": {
+						"Value1",
+						"Value2",
+					},
+				},
+				dimensionRegexps: SupportedServices.GetService("personalize").DimensionRegexps,
+				resources: []*tagsData{
+					{
+						ID: aws.String("arn:aws:personalize:us-east-1:123123123123:campaign/movies"),
+						Tags: []*Tag{
+							{
+								Key:   "Test",
+								Value: "Value",
+							},
+						},
+						Namespace: aws.String("personalize"),
+						Region:    aws.String("us-east-1"),
+					},
+				},
+				metricsList: []*cloudwatch.Metric{
+					{
+						MetricName: aws.String("GetRecommendations"),
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("CampaignArn"),
+								Value: aws.String("arn:aws:personalize:us-east-1:123123123123:campaign/movies"),
+							},
+						},
+						Namespace: aws.String("AWS/Personalize"),
+					},
+				},
+				m: &Metric{
+					Name: "GetRecommendations",
+					Statistics: []string{
+						"Average",
+					},
+					Period:                 60,
+					Length:                 600,
+					Delay:                  120,
+					NilToZero:              aws.Bool(false),
+					AddCloudwatchTimestamp: aws.Bool(false),
+				},
+			},
+			[]cloudwatchData{
+				{
+					AccountId:              aws.String("123123123123"),
+					AddCloudwatchTimestamp: aws.Bool(false),
+					Dimensions: []*cloudwatch.Dimension{
+						{
+							Name:  aws.String("CampaignArn"),
+							Value: aws.String("arn:aws:personalize:us-east-1:123123123123:campaign/movies"),
+						},
+					},
+					ID:        aws.String("arn:aws:personalize:us-east-1:123123123123:campaign/movies"),
+					Metric:    aws.String("GetRecommendations"),
+					Namespace: aws.String("personalize"),
+					NilToZero: aws.Bool(false),
+					Period:    60,
+					Region:    aws.String("us-east-1"),
+					Statistics: []string{
+						"Average",
+					},
+					Tags: []Tag{
+						{
+							Key:   "Value1",
+							Value: "",
+						},
+						{
+							Key:   "Value2",
+							Value: "",
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			for i, got := range getFilteredMetricDatas(tt.args.region, tt.args.accountId, tt.args.namespace, tt.args.customTags, tt.args.tagsOnMetrics, tt.args.dimensionRegexps, tt.args.resources, tt.args.metricsList, tt.args.m) {
+			metricDatas := getFilteredMetricDatas(tt.args.region, tt.args.accountId, tt.args.namespace, tt.args.customTags, tt.args.tagsOnMetrics, tt.args.dimensionRegexps, tt.args.resources, tt.args.metricsList, tt.args.m)
+			if len(metricDatas) != len(tt.wantGetMetricsData) {
+				t.Fatalf("len(getFilteredMetricDatas()) = %d, want %d", len(metricDatas), len(tt.wantGetMetricsData))
+			}
+			for i, got := range metricDatas {
 				if *got.AccountId != *tt.wantGetMetricsData[i].AccountId {
 					t.Errorf("getFilteredMetricDatas().AccountId = %v, want %v", *got.AccountId, *tt.wantGetMetricsData[i].AccountId)
 				}
@@ -281,3 +3637,128 @@ func Test_getFilteredMetricDatas(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricDimensionsMatchNames(t *testing.T) {
+	nodeMetric := &cloudwatch.Metric{
+		MetricName: aws.String("CPUUtilization"),
+		Dimensions: []*cloudwatch.Dimension{
+			{Name: aws.String("CacheClusterId"), Value: aws.String("redis-0001-001")},
+			{Name: aws.String("CacheNodeId"), Value: aws.String("0001")},
+		},
+	}
+	clusterMetric := &cloudwatch.Metric{
+		MetricName: aws.String("CPUUtilization"),
+		Dimensions: []*cloudwatch.Dimension{
+			{Name: aws.String("CacheClusterId"), Value: aws.String("redis-0001-001")},
+		},
+	}
+
+	equals(t, true, metricDimensionsMatchNames(nodeMetric, []string{"CacheNodeId", "CacheClusterId"}))
+	equals(t, false, metricDimensionsMatchNames(clusterMetric, []string{"CacheNodeId", "CacheClusterId"}))
+	equals(t, true, metricDimensionsMatchNames(clusterMetric, []string{"CacheClusterId"}))
+	equals(t, false, metricDimensionsMatchNames(nodeMetric, []string{"CacheClusterId"}))
+}
+
+func TestPercentileQuantile(t *testing.T) {
+	for statistic, expected := range map[string]string{"p90": "0.9", "p99": "0.99", "p99.9": "0.999", "p5": "0.05", "p100": "1", "p0": "0"} {
+		quantile, ok := percentileQuantile(statistic)
+		equals(t, true, ok)
+		equals(t, expected, quantile)
+	}
+	for _, statistic := range []string{"Average", "p", "p101", "tm99"} {
+		_, ok := percentileQuantile(statistic)
+		equals(t, false, ok)
+	}
+}
+
+func TestMigratePercentilesAsQuantiles(t *testing.T) {
+	var cwd []*cloudwatchData
+	now := time.Now()
+	for _, statistic := range []string{"p90", "p99.9", "Average"} {
+		cwd = append(cwd, &cloudwatchData{
+			ID:                      aws.String("alb-1"),
+			Metric:                  aws.String("QuantileResponseTime"),
+			Namespace:               aws.String("alb"),
+			Statistics:              []string{statistic},
+			GetMetricDataPoint:      aws.Float64(1),
+			GetMetricDataTimestamps: &now,
+			NilToZero:               aws.Bool(false),
+			Region:                  aws.String("us-east-1"),
+			AccountId:               aws.String("123123123123"),
+			PercentilesAsQuantiles:  true,
+		})
+	}
+
+	metrics := migrateCloudwatchToPrometheus(cwd, false)
+	equals(t, 3, len(metrics))
+	equals(t, "aws_alb_quantile_response_time", *metrics[0].name)
+	equals(t, "0.9", labelsOf(metrics[0])["quantile"])
+	equals(t, "aws_alb_quantile_response_time", *metrics[1].name)
+	equals(t, "0.999", labelsOf(metrics[1])["quantile"])
+	equals(t, "aws_alb_quantile_response_time_average", *metrics[2].name)
+	_, ok := labelsOf(metrics[2])["quantile"]
+	equals(t, false, ok)
+}
+
+func TestMigrateComputedAverage(t *testing.T) {
+	now := time.Now()
+	series := func(id, statistic string, value float64) *cloudwatchData {
+		return &cloudwatchData{
+			ID:                      aws.String(id),
+			Metric:                  aws.String("AverageLatency"),
+			Namespace:               aws.String("alb"),
+			Statistics:              []string{statistic},
+			GetMetricDataPoint:      aws.Float64(value),
+			GetMetricDataTimestamps: &now,
+			NilToZero:               aws.Bool(false),
+			Region:                  aws.String("us-east-1"),
+			AccountId:               aws.String("123123123123"),
+			ComputeAverage:          true,
+		}
+	}
+	cwd := []*cloudwatchData{
+		series("alb-1", "Sum", 10),
+		series("alb-2", "Sum", 3),
+		series("alb-1", "SampleCount", 4),
+		series("alb-2", "SampleCount", 0),
+	}
+
+	metrics := migrateCloudwatchToPrometheus(cwd, false)
+	// alb-2 has no samples, so no average
+	equals(t, 5, len(metrics))
+	average := metrics[4]
+	equals(t, "aws_alb_average_latency_computed_average", *average.name)
+	equals(t, "alb-1", labelsOf(average)["name"])
+	equals(t, 2.5, *average.value)
+}
+
+func TestTimeWindowRounding(t *testing.T) {
+	equals(t, 5*time.Minute, timeWindowRounding([]cloudwatchData{{Period: 60}, {Period: 300}}))
+	equals(t, 10*time.Second, timeWindowRounding([]cloudwatchData{{Period: 60}, {Period: 30}, {Period: 10}}))
+}
+
+func TestOffsetTimeWindow(t *testing.T) {
+	now := time.Date(2021, 3, 10, 14, 25, 0, 0, time.UTC)
+
+	startTime, endTime := offsetTimeWindow(24*time.Hour, 0, 86400, now)
+	equals(t, time.Date(2021, 3, 9, 0, 0, 0, 0, time.UTC), startTime)
+	equals(t, time.Date(2021, 3, 10, 0, 0, 0, 0, time.UTC), endTime)
+
+	startTime, endTime = offsetTimeWindow(24*time.Hour, 23*time.Hour, 3600, now)
+	equals(t, time.Date(2021, 3, 9, 14, 0, 0, 0, time.UTC), startTime)
+	equals(t, time.Date(2021, 3, 9, 15, 0, 0, 0, time.UTC), endTime)
+}
+
+func TestWarnUnretainedPeriodOnce(t *testing.T) {
+	ResetUnretainedPeriodWarnings()
+	startTime := time.Now().Add(-30 * 24 * time.Hour)
+
+	// Only the first scrape warns about a metric, until the config is reloaded
+	equals(t, true, warnUnretainedPeriod("AWS/EC2", "CPUUtilization", 60, startTime))
+	equals(t, false, warnUnretainedPeriod("AWS/EC2", "CPUUtilization", 60, startTime))
+	equals(t, true, warnUnretainedPeriod("AWS/EC2", "CPUUtilization", 120, startTime))
+	equals(t, true, warnUnretainedPeriod("AWS/ELB", "CPUUtilization", 60, startTime))
+	equals(t, false, warnUnretainedPeriod("AWS/EC2", "CPUUtilization", 300, startTime))
+	ResetUnretainedPeriodWarnings()
+	equals(t, true, warnUnretainedPeriod("AWS/EC2", "CPUUtilization", 60, startTime))
+}
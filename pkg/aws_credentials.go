@@ -0,0 +1,94 @@
+package exporter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"gopkg.in/yaml.v2"
+)
+
+// Credential sources of a role, the default credential chain is used without a source
+const (
+	credentialSourceEnv         = "env"
+	credentialSourceProfile     = "profile"
+	credentialSourceWebIdentity = "webIdentity"
+	credentialSourceSecretsFile = "secretsFile"
+)
+
+var credentialSources = []string{credentialSourceEnv, credentialSourceProfile, credentialSourceWebIdentity, credentialSourceSecretsFile}
+
+// secrets is the content of a secretsFile holding an access key pair
+type secrets struct {
+	AccessKeyID     string `yaml:"accessKeyId"`
+	SecretAccessKey string `yaml:"secretAccessKey"`
+	SessionToken    string `yaml:"sessionToken"`
+}
+
+func readSecretsFile(file string) (*secrets, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	s := &secrets{}
+	if err := yaml.Unmarshal(content, s); err != nil {
+		return nil, err
+	}
+	if s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return nil, fmt.Errorf("accessKeyId and secretAccessKey should not be empty")
+	}
+	return s, nil
+}
+
+// sourceCredentials returns the credentials of the role's credential source, or nil for the default credential chain
+func sourceCredentials(sess *session.Session, role Role, opts sessionOptions) *credentials.Credentials {
+	switch role.CredentialSource {
+	case credentialSourceEnv:
+		return credentials.NewEnvCredentials()
+	case credentialSourceProfile:
+		return credentials.NewSharedCredentials("", role.Profile)
+	case credentialSourceWebIdentity:
+		return credentials.NewCredentials(stscreds.NewWebIdentityRoleProvider(createAssumeRoleClient(sess, opts), os.Getenv("AWS_ROLE_ARN"), os.Getenv("AWS_ROLE_SESSION_NAME"), os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")))
+	case credentialSourceSecretsFile:
+		// The file is read for every session, so rotated keys are picked up with the next scrape
+		s, err := readSecretsFile(role.SecretsFile)
+		if err != nil {
+			return credentials.NewCredentials(&credentials.ErrorProvider{
+				Err:          fmt.Errorf("Couldn't read secrets file %s: %v", role.SecretsFile, err),
+				ProviderName: credentialSourceSecretsFile,
+			})
+		}
+		return credentials.NewStaticCredentials(s.AccessKeyID, s.SecretAccessKey, s.SessionToken)
+	}
+	return nil
+}
+
+// roleCredentials returns the credentials of a role, the credentials of its credential source used to assume its
+// RoleArn if it has one, or nil to use the credentials of the session
+func roleCredentials(sess *session.Session, role Role, opts sessionOptions) *credentials.Credentials {
+	creds := sourceCredentials(sess, role, opts)
+	if role.RoleArn == "" {
+		return creds
+	}
+	if creds != nil {
+		sess = sess.Copy(&aws.Config{Credentials: creds})
+	}
+	return stscreds.NewCredentialsWithClient(createAssumeRoleClient(sess, opts), role.RoleArn, func(p *stscreds.AssumeRoleProvider) {
+		if role.ExternalID != "" {
+			p.ExternalID = aws.String(role.ExternalID)
+		}
+	})
+}
+
+// createAssumeRoleClient creates the STS client assuming roles with the sts endpoint override, the endpoint of the
+// service the session is created for isn't used for it
+func createAssumeRoleClient(sess *session.Session, opts sessionOptions) *sts.STS {
+	config := &aws.Config{Region: sess.Config.Region}
+	setEndpoint(config, "sts", opts)
+	return sts.New(sess, config)
+}
@@ -0,0 +1,146 @@
+package exporter
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+)
+
+// Maximum number of resources per elasticloadbalancing:DescribeTags request
+const elbv2DescribeTagsLimit = 20
+
+func createELBv2Session(region *string, role Role, fips bool, opts sessionOptions) elbv2iface.ELBV2API {
+	maxELBv2APIRetries := 5
+	config := &aws.Config{Region: region, MaxRetries: &maxELBv2APIRetries}
+	if fips {
+		// ToDo: Elastic Load Balancing does not have a FIPS endpoint
+		// https://docs.aws.amazon.com/general/latest/gr/elb.html
+	}
+	setEndpoint(config, "elbv2", opts)
+	return elbv2.New(createSession(role, config, opts), config)
+}
+
+func createRDSSession(region *string, role Role, fips bool, opts sessionOptions) rdsiface.RDSAPI {
+	maxRDSAPIRetries := 5
+	config := &aws.Config{Region: region, MaxRetries: &maxRDSAPIRetries}
+	if fips {
+		// https://docs.aws.amazon.com/general/latest/gr/rds-service.html
+		endpoint := fmt.Sprintf("https://rds-fips.%s.amazonaws.com", *region)
+		config.Endpoint = aws.String(endpoint)
+	}
+	setEndpoint(config, "rds", opts)
+	return rds.New(createSession(role, config, opts), config)
+}
+
+// describeLoadBalancers returns the load balancers of a type ("application" or "network") with their tags from the elbv2 API
+func describeLoadBalancers(loadBalancerType string) ResourceFunc {
+	return func(iface tagsInterface, job *Job, region string) (resources []*tagsData, err error) {
+		var arns []*string
+		err = iface.elbv2Client.DescribeLoadBalancersPages(&elbv2.DescribeLoadBalancersInput{},
+			func(page *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+				elbv2APICounter.Inc()
+				for _, lb := range page.LoadBalancers {
+					if aws.StringValue(lb.Type) == loadBalancerType {
+						arns = append(arns, lb.LoadBalancerArn)
+					}
+				}
+				return !lastPage
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < len(arns); i += elbv2DescribeTagsLimit {
+			end := i + elbv2DescribeTagsLimit
+			if end > len(arns) {
+				end = len(arns)
+			}
+			output, err := iface.elbv2Client.DescribeTags(&elbv2.DescribeTagsInput{ResourceArns: arns[i:end]})
+			elbv2APICounter.Inc()
+			if err != nil {
+				return nil, err
+			}
+			for _, description := range output.TagDescriptions {
+				resource := tagsData{
+					ID:        description.ResourceArn,
+					Namespace: &job.Type,
+					Region:    &region,
+				}
+				for _, t := range description.Tags {
+					resource.Tags = append(resource.Tags, &Tag{Key: *t.Key, Value: aws.StringValue(t.Value)})
+				}
+				resources = append(resources, &resource)
+			}
+		}
+		return resources, nil
+	}
+}
+
+// describeDBInstancesAndClusters returns the RDS instances and clusters with their tags from the rds API
+func describeDBInstancesAndClusters(iface tagsInterface, job *Job, region string) (resources []*tagsData, err error) {
+	err = iface.rdsClient.DescribeDBInstancesPages(&rds.DescribeDBInstancesInput{},
+		func(page *rds.DescribeDBInstancesOutput, lastPage bool) bool {
+			rdsAPICounter.Inc()
+			for _, instance := range page.DBInstances {
+				resources = append(resources, rdsResource(instance.DBInstanceArn, instance.TagList, job, region))
+			}
+			return !lastPage
+		})
+	if err != nil {
+		return nil, err
+	}
+	err = iface.rdsClient.DescribeDBClustersPages(&rds.DescribeDBClustersInput{},
+		func(page *rds.DescribeDBClustersOutput, lastPage bool) bool {
+			rdsAPICounter.Inc()
+			for _, cluster := range page.DBClusters {
+				resources = append(resources, rdsResource(cluster.DBClusterArn, cluster.TagList, job, region))
+			}
+			return !lastPage
+		})
+	if err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+func rdsResource(arn *string, tags []*rds.Tag, job *Job, region string) *tagsData {
+	resource := tagsData{
+		ID:        arn,
+		Namespace: &job.Type,
+		Region:    &region,
+	}
+	for _, t := range tags {
+		resource.Tags = append(resource.Tags, &Tag{Key: *t.Key, Value: aws.StringValue(t.Value)})
+	}
+	return &resource
+}
+
+// mergeDescribedResources fills in the tags of resources returned without tags and adds the described resources
+// missing from resources if they match the search tags
+func mergeDescribedResources(job *Job, resources []*tagsData, described []*tagsData) []*tagsData {
+	byID := make(map[string]*tagsData, len(described))
+	for _, resource := range described {
+		byID[*resource.ID] = resource
+	}
+
+	merged := make([]*tagsData, 0, len(resources))
+	for _, resource := range resources {
+		if d, ok := byID[*resource.ID]; ok {
+			if len(resource.Tags) == 0 {
+				resource.Tags = d.Tags
+			}
+			delete(byID, *resource.ID)
+		}
+		merged = append(merged, resource)
+	}
+	for _, resource := range described {
+		if _, ok := byID[*resource.ID]; ok && resource.filterThroughTags(job.SearchTags) {
+			merged = append(merged, resource)
+		}
+	}
+	return merged
+}
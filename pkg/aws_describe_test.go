@@ -0,0 +1,75 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+)
+
+type mockELBv2Client struct {
+	elbv2iface.ELBV2API
+	loadBalancers []*elbv2.LoadBalancer
+	tags          map[string][]*elbv2.Tag
+}
+
+func (m mockELBv2Client) DescribeLoadBalancersPages(input *elbv2.DescribeLoadBalancersInput, fn func(*elbv2.DescribeLoadBalancersOutput, bool) bool) error {
+	fn(&elbv2.DescribeLoadBalancersOutput{LoadBalancers: m.loadBalancers}, true)
+	return nil
+}
+
+func (m mockELBv2Client) DescribeTags(input *elbv2.DescribeTagsInput) (*elbv2.DescribeTagsOutput, error) {
+	var descriptions []*elbv2.TagDescription
+	for _, arn := range input.ResourceArns {
+		descriptions = append(descriptions, &elbv2.TagDescription{ResourceArn: arn, Tags: m.tags[*arn]})
+	}
+	return &elbv2.DescribeTagsOutput{TagDescriptions: descriptions}, nil
+}
+
+func TestDescribeLoadBalancers(t *testing.T) {
+	alb := "arn:aws:elasticloadbalancing:us-east-1:123123123123:loadbalancer/app/web/abc"
+	nlb := "arn:aws:elasticloadbalancing:us-east-1:123123123123:loadbalancer/net/tcp/def"
+	iface := tagsInterface{
+		elbv2Client: mockELBv2Client{
+			loadBalancers: []*elbv2.LoadBalancer{
+				{LoadBalancerArn: aws.String(alb), Type: aws.String("application")},
+				{LoadBalancerArn: aws.String(nlb), Type: aws.String("network")},
+			},
+			tags: map[string][]*elbv2.Tag{
+				alb: {{Key: aws.String("team"), Value: aws.String("web")}},
+			},
+		},
+	}
+
+	resources, err := describeLoadBalancers("application")(iface, &Job{Type: "alb"}, "us-east-1")
+
+	equals(t, nil, err)
+	equals(t, 1, len(resources))
+	equals(t, alb, *resources[0].ID)
+	equals(t, []*Tag{{Key: "team", Value: "web"}}, resources[0].Tags)
+}
+
+func TestMergeDescribedResources(t *testing.T) {
+	job := &Job{Type: "rds", SearchTags: []Tag{{Key: "env", Value: "prod"}}}
+	untagged := &tagsData{ID: aws.String("arn:aws:rds:us-east-1:123123123123:db:untagged")}
+	tagged := &tagsData{ID: aws.String("arn:aws:rds:us-east-1:123123123123:db:tagged"), Tags: []*Tag{{Key: "env", Value: "prod"}}}
+	described := []*tagsData{
+		{ID: untagged.ID, Tags: []*Tag{{Key: "env", Value: "prod"}, {Key: "team", Value: "db"}}},
+		{ID: aws.String("arn:aws:rds:us-east-1:123123123123:db:missing"), Tags: []*Tag{{Key: "env", Value: "prod"}}},
+		{ID: aws.String("arn:aws:rds:us-east-1:123123123123:db:staging"), Tags: []*Tag{{Key: "env", Value: "staging"}}},
+	}
+
+	merged := mergeDescribedResources(job, []*tagsData{untagged, tagged}, described)
+
+	var ids []string
+	for _, resource := range merged {
+		ids = append(ids, *resource.ID)
+	}
+	equals(t, []string{
+		"arn:aws:rds:us-east-1:123123123123:db:untagged",
+		"arn:aws:rds:us-east-1:123123123123:db:tagged",
+		"arn:aws:rds:us-east-1:123123123123:db:missing",
+	}, ids)
+	equals(t, 2, len(merged[0].Tags))
+}
@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/organizations/organizationsiface"
+	log "github.com/sirupsen/logrus"
+)
+
+type organizationsInterface struct {
+	client organizationsiface.OrganizationsAPI
+}
+
+func createOrganizationsSession(role Role, opts sessionOptions) organizationsiface.OrganizationsAPI {
+	maxOrganizationsAPIRetries := 5
+	// Organizations is a global service only served from us-east-1
+	// https://docs.aws.amazon.com/general/latest/gr/ao.html
+	config := &aws.Config{Region: aws.String("us-east-1"), MaxRetries: &maxOrganizationsAPIRetries}
+	setEndpoint(config, "organizations", opts)
+	return organizations.New(createSession(role, config, opts), config)
+}
+
+// accountTags returns the requested tags of an account, empty tags are returned for tags the account doesn't have
+// to ensure the same labels are present on the metrics of all accounts.
+func (iface organizationsInterface) accountTags(accountId string, tagNames []string) ([]Tag, error) {
+	accountTags := make(map[string]string)
+	err := iface.client.ListTagsForResourcePages(&organizations.ListTagsForResourceInput{
+		ResourceId: aws.String(accountId),
+	}, func(page *organizations.ListTagsForResourceOutput, lastPage bool) bool {
+		organizationsAPICounter.Inc()
+		for _, t := range page.Tags {
+			accountTags[*t.Key] = *t.Value
+		}
+		return !lastPage
+	})
+
+	tags := make([]Tag, 0, len(tagNames))
+	for _, tagName := range tagNames {
+		tags = append(tags, Tag{Key: tagName, Value: accountTags[tagName]})
+	}
+	return tags, err
+}
+
+// accountTagsCache looks up the tags of every account once per scrape
+type accountTagsCache struct {
+	iface    organizationsInterface
+	tagNames []string
+	mux      sync.Mutex
+	tags     map[string][]Tag
+}
+
+func newAccountTagsCache(iface organizationsInterface, tagNames []string) *accountTagsCache {
+	return &accountTagsCache{
+		iface:    iface,
+		tagNames: tagNames,
+		tags:     make(map[string][]Tag),
+	}
+}
+
+func (c *accountTagsCache) get(accountId *string) []Tag {
+	if accountId == nil {
+		return nil
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if tags, ok := c.tags[*accountId]; ok {
+		return tags
+	}
+	tags, err := c.iface.accountTags(*accountId, c.tagNames)
+	if err != nil {
+		log.Warningf("Couldn't get organization tags of account %s: %s", *accountId, err.Error())
+	}
+	c.tags[*accountId] = tags
+	return tags
+}
+
+// apply sets the tags of the account on the resources and metrics of its jobs, nothing is set without exported
+// account tags
+func (c *accountTagsCache) apply(accountId *string, resources []*tagsData, metrics []*cloudwatchData) {
+	if c == nil {
+		return
+	}
+	tags := c.get(accountId)
+	for _, resource := range resources {
+		resource.AccountTags = tags
+	}
+	for _, metric := range metrics {
+		metric.AccountTags = tags
+	}
+}
@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/organizations/organizationsiface"
+)
+
+type mockOrganizationsClient struct {
+	organizationsiface.OrganizationsAPI
+	tags  map[string][]*organizations.Tag
+	calls int
+}
+
+func (m *mockOrganizationsClient) ListTagsForResourcePages(input *organizations.ListTagsForResourceInput, fn func(*organizations.ListTagsForResourceOutput, bool) bool) error {
+	m.calls++
+	tags, ok := m.tags[*input.ResourceId]
+	if !ok {
+		return &request.ErrInvalidParams{Context: "ListTagsForResource"}
+	}
+	fn(&organizations.ListTagsForResourceOutput{Tags: tags}, true)
+	return nil
+}
+
+func TestAccountTagsCache(t *testing.T) {
+	client := &mockOrganizationsClient{
+		tags: map[string][]*organizations.Tag{
+			"123123123123": {
+				{Key: aws.String("team"), Value: aws.String("platform")},
+				{Key: aws.String("cost-center"), Value: aws.String("42")},
+			},
+		},
+	}
+	cache := newAccountTagsCache(organizationsInterface{client: client}, []string{"team", "environment"})
+
+	expected := []Tag{{Key: "team", Value: "platform"}, {Key: "environment", Value: ""}}
+	equals(t, expected, cache.get(aws.String("123123123123")))
+	equals(t, expected, cache.get(aws.String("123123123123")))
+	equals(t, 1, client.calls)
+
+	// Accounts which can't be looked up still get the labels to keep them consistent across accounts
+	equals(t, []Tag{{Key: "team", Value: ""}, {Key: "environment", Value: ""}}, cache.get(aws.String("456456456456")))
+	equals(t, 2, client.calls)
+}
+
+func TestAccountTagsCacheApply(t *testing.T) {
+	client := &mockOrganizationsClient{
+		tags: map[string][]*organizations.Tag{
+			"123123123123": {{Key: aws.String("team"), Value: aws.String("platform")}},
+		},
+	}
+	cache := newAccountTagsCache(organizationsInterface{client: client}, []string{"team"})
+
+	// Static jobs get the account tags like discovery jobs
+	job := &Static{
+		Name:      "requests",
+		Namespace: "AWS/ELB",
+		Metrics:   []*Metric{{Name: "RequestCount", Statistics: []string{"Sum"}, Period: 60, Length: 300}},
+	}
+	regions.startScrape()
+	metrics, _ := scrapeStaticJob(job, "eu-west-1", Role{}, aws.String("123123123123"), cloudwatchInterface{client: mockCloudwatchClient{}}, make(chan struct{}, 1))
+	resources := []*tagsData{{ID: aws.String("arn:aws:elasticloadbalancing:eu-west-1:123123123123:loadbalancer/lb"), Namespace: aws.String("elb")}}
+	cache.apply(aws.String("123123123123"), resources, metrics)
+
+	equals(t, "platform", labelsOf(migrateCloudwatchToPrometheus(metrics, false)[0])["account_tag_team"])
+	equals(t, "platform", labelsOf(migrateTagsToPrometheus(resources, false)[0])["account_tag_team"])
+
+	// Without exported account tags nothing is set
+	var disabled *accountTagsCache
+	metrics[0].AccountTags = nil
+	disabled.apply(aws.String("123123123123"), resources[:0], metrics)
+	equals(t, []Tag(nil), metrics[0].AccountTags)
+}
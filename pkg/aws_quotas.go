@@ -0,0 +1,249 @@
+package exporter
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
+	log "github.com/sirupsen/logrus"
+)
+
+// The CloudWatch operations limited to a fraction of their Service Quotas rate quota
+var rateLimitedCloudwatchOperations = []string{"GetMetricData", "GetMetricStatistics", "ListMetrics"}
+
+// cloudwatchRateLimiters holds the rate limiters of every role and region, quotas are only looked up once
+var cloudwatchRateLimiters = struct {
+	mux      sync.Mutex
+	limiters map[discoveryJobKey]map[string]*rateLimiter
+}{
+	limiters: make(map[discoveryJobKey]map[string]*rateLimiter),
+}
+
+type rateLimiter struct {
+	mux      sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(requestsPerSecond float64) *rateLimiter {
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / requestsPerSecond),
+	}
+}
+
+// wait blocks until the next request is allowed
+func (l *rateLimiter) wait() {
+	l.mux.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mux.Unlock()
+	time.Sleep(delay)
+}
+
+func createServiceQuotasSession(region *string, role Role, fips bool, opts sessionOptions) servicequotasiface.ServiceQuotasAPI {
+	maxServiceQuotasAPIRetries := 5
+	config := &aws.Config{Region: region, MaxRetries: &maxServiceQuotasAPIRetries}
+	if fips {
+		// ToDo: Service Quotas does not have a FIPS endpoint
+		// https://docs.aws.amazon.com/general/latest/gr/servicequotas.html
+	}
+	setEndpoint(config, "servicequotas", opts)
+	return servicequotas.New(createSession(role, config, opts), config)
+}
+
+// getCloudwatchQuotas returns the requests per second quotas of the rate limited CloudWatch operations
+func getCloudwatchQuotas(client servicequotasiface.ServiceQuotasAPI) (map[string]float64, error) {
+	quotas := make(map[string]float64)
+	err := client.ListServiceQuotasPages(&servicequotas.ListServiceQuotasInput{
+		ServiceCode: aws.String("monitoring"),
+	}, func(page *servicequotas.ListServiceQuotasOutput, lastPage bool) bool {
+		serviceQuotasAPICounter.Inc()
+		for _, quota := range page.Quotas {
+			if quota.QuotaName == nil || quota.Value == nil {
+				continue
+			}
+			// Quotas are named like "Rate of GetMetricData requests"
+			for _, operation := range rateLimitedCloudwatchOperations {
+				if stringInSlice(operation, strings.Fields(*quota.QuotaName)) {
+					quotas[operation] = *quota.Value
+				}
+			}
+		}
+		return !lastPage
+	})
+	return quotas, err
+}
+
+func createCloudwatchRateLimiters(quotas map[string]float64, fraction float64) map[string]*rateLimiter {
+	limiters := make(map[string]*rateLimiter)
+	for operation, quota := range quotas {
+		if requestsPerSecond := quota * fraction; requestsPerSecond > 0 {
+			limiters[operation] = newRateLimiter(requestsPerSecond)
+		}
+	}
+	return limiters
+}
+
+func getCloudwatchRateLimiters(region *string, role Role, fips bool, opts sessionOptions, fraction float64) map[string]*rateLimiter {
+	key := discoveryJobKey{role: role.key(), region: *region}
+	cloudwatchRateLimiters.mux.Lock()
+	defer cloudwatchRateLimiters.mux.Unlock()
+	if limiters, ok := cloudwatchRateLimiters.limiters[key]; ok {
+		return limiters
+	}
+	quotas, err := getCloudwatchQuotas(createServiceQuotasSession(region, role, fips, opts))
+	if err != nil {
+		log.Warningf("Couldn't get CloudWatch quotas for role %s in %s, requests are not rate limited: %v", role.RoleArn, *region, err)
+	}
+	for operation, quota := range quotas {
+		log.Infof("Limiting CloudWatch %s requests for role %s in %s to %.2f per second", operation, role.RoleArn, *region, quota*fraction)
+	}
+	limiters := createCloudwatchRateLimiters(quotas, fraction)
+	cloudwatchRateLimiters.limiters[key] = limiters
+	return limiters
+}
+
+// rateLimitCloudwatch makes the client wait for the rate limiter of an operation before sending a request
+func rateLimitCloudwatch(handlers *request.Handlers, limiters map[string]*rateLimiter) {
+	handlers.Send.PushFront(func(r *request.Request) {
+		if limiter, ok := limiters[r.Operation.Name]; ok {
+			limiter.wait()
+		}
+	})
+}
+
+// Quotas only change on increase requests, the quotas of usage metrics are listed again after this time
+const usageQuotasRefresh = time.Hour
+
+type usageQuotasKey struct {
+	role        string
+	region      string
+	serviceCode string
+}
+
+type usageQuotasEntry struct {
+	time   time.Time
+	quotas []*servicequotas.ServiceQuota
+}
+
+// usageQuotas caches the quotas of the services of every role and region joined with their AWS/Usage metrics
+var usageQuotas = struct {
+	mux     sync.Mutex
+	entries map[usageQuotasKey]usageQuotasEntry
+}{
+	entries: make(map[usageQuotasKey]usageQuotasEntry),
+}
+
+// getUsageQuotas returns the quotas of a service measured by a usage metric, applied quotas override the defaults
+func getUsageQuotas(client servicequotasiface.ServiceQuotasAPI, serviceCode string) ([]*servicequotas.ServiceQuota, error) {
+	var codes []string
+	quotas := make(map[string]*servicequotas.ServiceQuota)
+	add := func(page []*servicequotas.ServiceQuota) {
+		serviceQuotasAPICounter.Inc()
+		for _, quota := range page {
+			if quota.QuotaCode == nil || quota.Value == nil || quota.UsageMetric == nil {
+				continue
+			}
+			if _, ok := quotas[*quota.QuotaCode]; !ok {
+				codes = append(codes, *quota.QuotaCode)
+			}
+			quotas[*quota.QuotaCode] = quota
+		}
+	}
+	err := client.ListAWSDefaultServiceQuotasPages(&servicequotas.ListAWSDefaultServiceQuotasInput{
+		ServiceCode: aws.String(serviceCode),
+	}, func(page *servicequotas.ListAWSDefaultServiceQuotasOutput, lastPage bool) bool {
+		add(page.Quotas)
+		return !lastPage
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = client.ListServiceQuotasPages(&servicequotas.ListServiceQuotasInput{
+		ServiceCode: aws.String(serviceCode),
+	}, func(page *servicequotas.ListServiceQuotasOutput, lastPage bool) bool {
+		add(page.Quotas)
+		return !lastPage
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*servicequotas.ServiceQuota, 0, len(codes))
+	for _, code := range codes {
+		result = append(result, quotas[code])
+	}
+	return result, nil
+}
+
+// getCachedUsageQuotas returns the quotas of the services for a role and region, services whose quotas can't be listed
+// keep their last quotas
+func getCachedUsageQuotas(client servicequotasiface.ServiceQuotasAPI, role Role, region string, serviceCodes []string, now time.Time) []*servicequotas.ServiceQuota {
+	usageQuotas.mux.Lock()
+	defer usageQuotas.mux.Unlock()
+	var quotas []*servicequotas.ServiceQuota
+	for _, serviceCode := range serviceCodes {
+		key := usageQuotasKey{role: role.key(), region: region, serviceCode: serviceCode}
+		entry, ok := usageQuotas.entries[key]
+		if !ok || now.Sub(entry.time) >= usageQuotasRefresh {
+			serviceQuotas, err := getUsageQuotas(client, serviceCode)
+			if err != nil {
+				log.Warningf("Couldn't list the %s quotas for role %s in %s: %v", serviceCode, role.RoleArn, region, err)
+			} else {
+				entry = usageQuotasEntry{time: now, quotas: serviceQuotas}
+				usageQuotas.entries[key] = entry
+			}
+		}
+		quotas = append(quotas, entry.quotas...)
+	}
+	return quotas
+}
+
+// createServiceQuotaMetrics creates a Quota datapoint with the labels of every usage series of the job whose usage a
+// quota measures, so the utilization is the usage divided by the quota
+func createServiceQuotaMetrics(job *Job, quotas []*servicequotas.ServiceQuota, cw []*cloudwatchData, now time.Time) []*cloudwatchData {
+	var quotaMetrics []*cloudwatchData
+	for _, data := range cw {
+		if *data.Namespace != job.Type {
+			continue
+		}
+		for _, quota := range quotas {
+			if !measuresUsage(quota.UsageMetric, data) {
+				continue
+			}
+			quotaMetric := *data
+			quotaMetric.Statistics = []string{"Quota"}
+			quotaMetric.ComputeAverage = false
+			quotaMetric.PercentilesAsQuantiles = false
+			quotaMetric.GetMetricDataPoint = quota.Value
+			quotaMetric.GetMetricDataTimestamps = &now
+			quotaMetrics = append(quotaMetrics, &quotaMetric)
+			break
+		}
+	}
+	return quotaMetrics
+}
+
+// measuresUsage returns whether the usage metric of a quota is the usage series, with the same name and dimensions
+func measuresUsage(usage *servicequotas.MetricInfo, data *cloudwatchData) bool {
+	if aws.StringValue(usage.MetricNamespace) != "AWS/Usage" || aws.StringValue(usage.MetricName) != *data.Metric {
+		return false
+	}
+	if len(usage.MetricDimensions) != len(data.Dimensions) {
+		return false
+	}
+	for _, dimension := range data.Dimensions {
+		value, ok := usage.MetricDimensions[*dimension.Name]
+		if !ok || aws.StringValue(value) != *dimension.Value {
+			return false
+		}
+	}
+	return true
+}
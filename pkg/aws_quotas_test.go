@@ -0,0 +1,129 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
+)
+
+type mockServiceQuotasClient struct {
+	servicequotasiface.ServiceQuotasAPI
+	quotas   []*servicequotas.ServiceQuota
+	defaults []*servicequotas.ServiceQuota
+}
+
+func (m mockServiceQuotasClient) ListAWSDefaultServiceQuotasPages(input *servicequotas.ListAWSDefaultServiceQuotasInput, fn func(*servicequotas.ListAWSDefaultServiceQuotasOutput, bool) bool) error {
+	fn(&servicequotas.ListAWSDefaultServiceQuotasOutput{Quotas: m.defaults}, true)
+	return nil
+}
+
+func (m mockServiceQuotasClient) ListServiceQuotasPages(input *servicequotas.ListServiceQuotasInput, fn func(*servicequotas.ListServiceQuotasOutput, bool) bool) error {
+	fn(&servicequotas.ListServiceQuotasOutput{Quotas: m.quotas}, true)
+	return nil
+}
+
+func TestGetCloudwatchQuotas(t *testing.T) {
+	client := mockServiceQuotasClient{
+		quotas: []*servicequotas.ServiceQuota{
+			{QuotaName: aws.String("Rate of GetMetricData requests"), Value: aws.Float64(50)},
+			{QuotaName: aws.String("Rate of GetMetricStatistics requests"), Value: aws.Float64(400)},
+			{QuotaName: aws.String("Rate of ListMetrics requests"), Value: aws.Float64(25)},
+			{QuotaName: aws.String("Rate of GetMetricWidgetImage requests"), Value: aws.Float64(20)},
+		},
+	}
+
+	quotas, err := getCloudwatchQuotas(client)
+
+	equals(t, nil, err)
+	equals(t, map[string]float64{"GetMetricData": 50, "GetMetricStatistics": 400, "ListMetrics": 25}, quotas)
+
+	limiters := createCloudwatchRateLimiters(quotas, 0.5)
+	equals(t, 40*time.Millisecond, limiters["GetMetricData"].interval)
+	equals(t, 80*time.Millisecond, limiters["ListMetrics"].interval)
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(100)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		limiter.wait()
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("expected 5 requests at 100/s to take at least 40ms, took %s", elapsed)
+	}
+}
+
+func usageQuota(code string, value float64, metric string, dimensions map[string]string) *servicequotas.ServiceQuota {
+	return &servicequotas.ServiceQuota{
+		QuotaCode: aws.String(code),
+		Value:     aws.Float64(value),
+		UsageMetric: &servicequotas.MetricInfo{
+			MetricNamespace:  aws.String("AWS/Usage"),
+			MetricName:       aws.String(metric),
+			MetricDimensions: aws.StringMap(dimensions),
+		},
+	}
+}
+
+func TestServiceQuotaMetrics(t *testing.T) {
+	vcpus := map[string]string{"Service": "EC2", "Type": "Resource", "Resource": "vCPU", "Class": "Standard/OnDemand"}
+	client := mockServiceQuotasClient{
+		defaults: []*servicequotas.ServiceQuota{
+			usageQuota("L-1216C47A", 5, "ResourceCount", vcpus),
+			usageQuota("L-0263D0A3", 5, "ResourceCount", map[string]string{"Service": "EC2", "Type": "Resource", "Resource": "ElasticIP"}),
+			// Quotas without a usage metric can't be joined
+			{QuotaCode: aws.String("L-0E3CBAB9"), Value: aws.Float64(10)},
+		},
+		// The applied quota overrides the default
+		quotas: []*servicequotas.ServiceQuota{usageQuota("L-1216C47A", 256, "ResourceCount", vcpus)},
+	}
+	quotas, err := getUsageQuotas(client, "ec2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	equals(t, 2, len(quotas))
+	equals(t, float64(256), *quotas[0].Value)
+
+	job := &Job{Type: "usage", ServiceQuotas: []string{"ec2"}}
+	dimensions := func(values map[string]string) []*cloudwatch.Dimension {
+		var result []*cloudwatch.Dimension
+		for _, name := range []string{"Class", "Resource", "Service", "Type"} {
+			if value, ok := values[name]; ok {
+				result = append(result, &cloudwatch.Dimension{Name: aws.String(name), Value: aws.String(value)})
+			}
+		}
+		return result
+	}
+	now := time.Now()
+	usage := &cloudwatchData{
+		ID:                      aws.String("global"),
+		Metric:                  aws.String("ResourceCount"),
+		Namespace:               aws.String("usage"),
+		Statistics:              []string{"Maximum"},
+		Dimensions:              dimensions(vcpus),
+		NilToZero:               aws.Bool(false),
+		Region:                  aws.String("eu-west-1"),
+		AccountId:               aws.String("123123123123"),
+		GetMetricDataPoint:      aws.Float64(128),
+		GetMetricDataTimestamps: &now,
+	}
+	otherClass := *usage
+	otherClass.Dimensions = dimensions(map[string]string{"Service": "EC2", "Type": "Resource", "Resource": "vCPU", "Class": "Standard/Spot"})
+	callCount := *usage
+	callCount.Metric = aws.String("CallCount")
+
+	quotaMetrics := createServiceQuotaMetrics(job, quotas, []*cloudwatchData{usage, &otherClass, &callCount}, now)
+	equals(t, 1, len(quotaMetrics))
+	equals(t, []string{"Quota"}, quotaMetrics[0].Statistics)
+	equals(t, float64(256), *quotaMetrics[0].GetMetricDataPoint)
+
+	// The quota is exported next to the usage metric with the same labels
+	metrics := migrateCloudwatchToPrometheus([]*cloudwatchData{usage, quotaMetrics[0]}, false)
+	equals(t, "aws_usage_resource_count_maximum", *metrics[0].name)
+	equals(t, "aws_usage_resource_count_quota", *metrics[1].name)
+	equals(t, labelsOf(metrics[0]), labelsOf(metrics[1]))
+}
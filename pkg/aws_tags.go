@@ -4,52 +4,146 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	awsendpoints "github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/apigateway"
 	"github.com/aws/aws-sdk-go/service/apigateway/apigatewayiface"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/databasemigrationservice"
+	"github.com/aws/aws-sdk-go/service/databasemigrationservice/databasemigrationserviceiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/aws/aws-sdk-go/service/mediapackage"
+	"github.com/aws/aws-sdk-go/service/mediapackage/mediapackageiface"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
 	r "github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+	"github.com/aws/aws-sdk-go/service/shield"
+	"github.com/aws/aws-sdk-go/service/shield/shieldiface"
 	log "github.com/sirupsen/logrus"
 )
 
 type tagsData struct {
-	ID        *string
-	Tags      []*Tag
-	Namespace *string
-	Region    *string
+	ID          *string
+	Tags        []*Tag
+	Namespace   *string
+	Region      *string
+	AccountTags []Tag
+	RoleLabels  map[string]string
 }
 
 // https://docs.aws.amazon.com/sdk-for-go/api/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface/
 type tagsInterface struct {
-	client           resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
-	asgClient        autoscalingiface.AutoScalingAPI
-	apiGatewayClient apigatewayiface.APIGatewayAPI
-	ec2Client        ec2iface.EC2API
+	client             resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
+	asgClient          autoscalingiface.AutoScalingAPI
+	apiGatewayClient   apigatewayiface.APIGatewayAPI
+	ec2Client          ec2iface.EC2API
+	dmsClient          databasemigrationserviceiface.DatabaseMigrationServiceAPI
+	mediaPackageClient mediapackageiface.MediaPackageAPI
+	shieldClient       shieldiface.ShieldAPI
+	elbv2Client        elbv2iface.ELBV2API
+	rdsClient          rdsiface.RDSAPI
 }
 
-func createSession(role Role, config *aws.Config) *session.Session {
-	sess, err := session.NewSession(config)
+// sessionOptions are the settings of the AWS clients taken from the config of a scrape. They're passed to every client
+// created for the scrape instead of being package state, so scrapes of reloaded configs don't race.
+type sessionOptions struct {
+	// userAgent is appended to the User-Agent of all AWS requests, so the requests can be attributed to this exporter in CloudTrail
+	userAgent string
+	// endpoints overrides the endpoints of services, "{region}" is replaced with the region of the client
+	endpoints map[string]string
+	// useDualStack makes clients use the dual-stack (IPv4 and IPv6) endpoints of services that have one
+	useDualStack bool
+}
+
+func newSessionOptions(config ScrapeConf) sessionOptions {
+	return sessionOptions{
+		userAgent:    config.UserAgent,
+		endpoints:    config.Endpoints,
+		useDualStack: config.UseDualStackEndpoints,
+	}
+}
+
+// The services whose endpoints can be overridden
+var endpointServices = []string{
+	"apigateway", "autoscaling", "cloudwatch", "dms", "ec2", "elbv2", "mediapackage",
+	"organizations", "rds", "servicequotas", "shield", "sts", "tagging",
+}
+
+// The endpoint prefixes of the services with dual-stack endpoints. The SDK only knows the dual-stack endpoints of S3,
+// services without one, e.g. organizations, shield or tagging, keep their IPv4 endpoint.
+var dualStackServices = map[string]bool{
+	"ec2":                  true,
+	"elasticloadbalancing": true,
+	"monitoring":           true,
+	"rds":                  true,
+}
+
+// The DNS suffixes of the dual-stack endpoints of the partitions
+var dualStackDNSSuffixes = map[string]string{
+	awsendpoints.AwsPartitionID:      "api.aws",
+	awsendpoints.AwsUsGovPartitionID: "api.aws",
+	awsendpoints.AwsCnPartitionID:    "api.amazonwebservices.com.cn",
+}
+
+// dualStackResolver resolves the dual-stack endpoints of dualStackServices, e.g. https://monitoring.eu-west-1.api.aws,
+// from the endpoint prefix of the service and the partition of the region. Signing stays the same as for the IPv4
+// endpoint, other services are resolved by the default resolver.
+var dualStackResolver = awsendpoints.ResolverFunc(func(service, region string, opts ...func(*awsendpoints.Options)) (awsendpoints.ResolvedEndpoint, error) {
+	resolved, err := awsendpoints.DefaultResolver().EndpointFor(service, region, opts...)
 	if err != nil {
-		log.Fatalf("Failed to create session due to %v", err)
+		return resolved, err
 	}
-	if role.RoleArn != "" {
-		config.Credentials = stscreds.NewCredentials(sess, role.RoleArn, func(p *stscreds.AssumeRoleProvider) {
-			if role.ExternalID != "" {
-				p.ExternalID = aws.String(role.ExternalID)
-			}
-		})
+	suffix, ok := dualStackDNSSuffixes[resolved.PartitionID]
+	if !ok || !dualStackServices[service] {
+		return resolved, nil
+	}
+	resolved.URL = fmt.Sprintf("https://%s.%s.%s", service, resolved.SigningRegion, suffix)
+	return resolved, nil
+})
+
+// setEndpoint resolves dual-stack endpoints if configured and sets the endpoint of the config to the configured
+// endpoint of the service, if any
+func setEndpoint(config *aws.Config, service string, opts sessionOptions) {
+	if opts.useDualStack {
+		config.EndpointResolver = dualStackResolver
+	}
+	endpoint, ok := opts.endpoints[service]
+	if !ok {
+		return
+	}
+	config.Endpoint = aws.String(strings.ReplaceAll(endpoint, "{region}", aws.StringValue(config.Region)))
+}
+
+// addUserAgent appends userAgent to the User-Agent of the session's requests
+func addUserAgent(sess *session.Session, userAgent string) {
+	if userAgent != "" {
+		sess.Handlers.Build.PushBack(request.MakeAddToUserAgentFreeFormHandler(userAgent))
 	}
+}
+
+// createSession creates the session of a client config, the endpoint of the config is only used by the client and not
+// for the other clients of the session, e.g. the STS client assuming the role
+func createSession(role Role, config *aws.Config, opts sessionOptions) *session.Session {
+	sessionConfig := config.Copy()
+	sessionConfig.Endpoint = nil
+	sess, err := session.NewSession(sessionConfig)
+	if err != nil {
+		log.Fatalf("Failed to create session due to %v", err)
+	}
+	addUserAgent(sess, opts.userAgent)
+	skipDownRegion(&sess.Handlers, role, aws.StringValue(config.Region))
+	config.Credentials = roleCredentials(sess, role, opts)
 	return sess
 }
 
-func createTagSession(region *string, role Role, fips bool) *r.ResourceGroupsTaggingAPI {
+func createTagSession(region *string, role Role, fips bool, opts sessionOptions) *r.ResourceGroupsTaggingAPI {
 	maxResourceGroupTaggingRetries := 5
 	config := &aws.Config{Region: region, MaxRetries: &maxResourceGroupTaggingRetries}
 	if fips {
@@ -58,10 +152,11 @@ func createTagSession(region *string, role Role, fips bool) *r.ResourceGroupsTag
 		// endpoint := fmt.Sprintf("https://tagging-fips.%s.amazonaws.com", *region)
 		// config.Endpoint = aws.String(endpoint)
 	}
-	return r.New(createSession(role, config), config)
+	setEndpoint(config, "tagging", opts)
+	return r.New(createSession(role, config, opts), config)
 }
 
-func createASGSession(region *string, role Role, fips bool) autoscalingiface.AutoScalingAPI {
+func createASGSession(region *string, role Role, fips bool, opts sessionOptions) autoscalingiface.AutoScalingAPI {
 	maxAutoScalingAPIRetries := 5
 	config := &aws.Config{Region: region, MaxRetries: &maxAutoScalingAPIRetries}
 	if fips {
@@ -70,10 +165,11 @@ func createASGSession(region *string, role Role, fips bool) autoscalingiface.Aut
 		// endpoint := fmt.Sprintf("https://autoscaling-plans-fips.%s.amazonaws.com", *region)
 		// config.Endpoint = aws.String(endpoint)
 	}
-	return autoscaling.New(createSession(role, config), config)
+	setEndpoint(config, "autoscaling", opts)
+	return autoscaling.New(createSession(role, config, opts), config)
 }
 
-func createEC2Session(region *string, role Role, fips bool) ec2iface.EC2API {
+func createEC2Session(region *string, role Role, fips bool, opts sessionOptions) ec2iface.EC2API {
 	maxEC2APIRetries := 10
 	config := &aws.Config{Region: region, MaxRetries: &maxEC2APIRetries}
 	if fips {
@@ -81,10 +177,11 @@ func createEC2Session(region *string, role Role, fips bool) ec2iface.EC2API {
 		endpoint := fmt.Sprintf("https://ec2-fips.%s.amazonaws.com", *region)
 		config.Endpoint = aws.String(endpoint)
 	}
-	return ec2.New(createSession(role, config), config)
+	setEndpoint(config, "ec2", opts)
+	return ec2.New(createSession(role, config, opts), config)
 }
 
-func createAPIGatewaySession(region *string, role Role, fips bool) apigatewayiface.APIGatewayAPI {
+func createAPIGatewaySession(region *string, role Role, fips bool, opts sessionOptions) apigatewayiface.APIGatewayAPI {
 	maxApiGatewaygAPIRetries := 5
 	config := &aws.Config{Region: region, MaxRetries: &maxApiGatewaygAPIRetries}
 	if fips {
@@ -92,56 +189,93 @@ func createAPIGatewaySession(region *string, role Role, fips bool) apigatewayifa
 		endpoint := fmt.Sprintf("https://apigateway-fips.%s.amazonaws.com", *region)
 		config.Endpoint = aws.String(endpoint)
 	}
-	return apigateway.New(createSession(role, config), config)
+	setEndpoint(config, "apigateway", opts)
+	return apigateway.New(createSession(role, config, opts), config)
 }
 
-func (iface tagsInterface) get(job *Job, region string) (resources []*tagsData, err error) {
-	svc := SupportedServices.GetService(job.Type)
-	if len(svc.ResourceFilters) > 0 {
-		var inputparams = r.GetResourcesInput{
-			ResourceTypeFilters: svc.ResourceFilters,
-		}
-		c := iface.client
-		ctx := context.Background()
-		pageNum := 0
+func createDMSSession(region *string, role Role, fips bool, opts sessionOptions) databasemigrationserviceiface.DatabaseMigrationServiceAPI {
+	maxDMSAPIRetries := 5
+	config := &aws.Config{Region: region, MaxRetries: &maxDMSAPIRetries}
+	if fips {
+		// https://docs.aws.amazon.com/general/latest/gr/dms.html
+		endpoint := fmt.Sprintf("https://dms-fips.%s.amazonaws.com", *region)
+		config.Endpoint = aws.String(endpoint)
+	}
+	setEndpoint(config, "dms", opts)
+	return databasemigrationservice.New(createSession(role, config, opts), config)
+}
 
-		err = c.GetResourcesPagesWithContext(ctx, &inputparams, func(page *r.GetResourcesOutput, lastPage bool) bool {
-			pageNum++
-			resourceGroupTaggingAPICounter.Inc()
+func createMediaPackageSession(region *string, role Role, fips bool, opts sessionOptions) mediapackageiface.MediaPackageAPI {
+	maxMediaPackageAPIRetries := 5
+	config := &aws.Config{Region: region, MaxRetries: &maxMediaPackageAPIRetries}
+	if fips {
+		// ToDo: MediaPackage does not have a FIPS endpoint
+		// https://docs.aws.amazon.com/general/latest/gr/mediapackage.html
+	}
+	setEndpoint(config, "mediapackage", opts)
+	return mediapackage.New(createSession(role, config, opts), config)
+}
 
-			if len(page.ResourceTagMappingList) == 0 {
-				log.Debugf("Resource tag list is empty. Tags must be defined for %s to be discovered.", job.Type)
-			}
+func createShieldSession(role Role, fips bool, opts sessionOptions) shieldiface.ShieldAPI {
+	maxShieldAPIRetries := 5
+	// Shield Advanced is a global service only served from us-east-1
+	// https://docs.aws.amazon.com/general/latest/gr/shield.html
+	config := &aws.Config{Region: aws.String("us-east-1"), MaxRetries: &maxShieldAPIRetries}
+	if fips {
+		// ToDo: Shield does not have a FIPS endpoint
+	}
+	setEndpoint(config, "shield", opts)
+	return shield.New(createSession(role, config, opts), config)
+}
 
-			for _, resourceTagMapping := range page.ResourceTagMappingList {
-				resource := tagsData{
-					ID:        resourceTagMapping.ResourceARN,
-					Namespace: &job.Type,
-					Region:    &region,
-				}
+func (iface tagsInterface) get(job *Job, region string, tagSemaphore chan struct{}) (resources []*tagsData, err error) {
+	svc := SupportedServices.GetService(job.Type)
 
-				for _, t := range resourceTagMapping.Tags {
-					resource.Tags = append(resource.Tags, &Tag{Key: *t.Key, Value: *t.Value})
-				}
+	// Every resource type filter is paginated on its own, so the listings can run concurrently
+	filterResources := make([][]*tagsData, len(svc.ResourceFilters))
+	filterErrors := make([]error, len(svc.ResourceFilters))
+	var wg sync.WaitGroup
+	for i, filter := range svc.ResourceFilters {
+		wg.Add(1)
+		go func(i int, filter *string) {
+			defer wg.Done()
+			tagSemaphore <- struct{}{}
+			defer func() {
+				<-tagSemaphore
+			}()
+			filterResources[i], filterErrors[i] = iface.getResources(job, region, filter)
+		}(i, filter)
+	}
+	wg.Wait()
+	for i := range svc.ResourceFilters {
+		if filterErrors[i] != nil {
+			err = filterErrors[i]
+		}
+		resources = append(resources, filterResources[i]...)
+	}
 
-				if resource.filterThroughTags(job.SearchTags) {
-					resources = append(resources, &resource)
-				} else {
-					log.Debugf("Skipping resource %s because search tags do not match", *resource.ID)
-				}
-			}
-			return pageNum < 100
-		})
+	if job.TagsFallback {
+		tagSemaphore <- struct{}{}
+		described, err := svc.DescribeFunc(iface, job, region)
+		<-tagSemaphore
+		if err != nil {
+			return nil, err
+		}
+		resources = mergeDescribedResources(job, resources, described)
 	}
 	if svc.ResourceFunc != nil {
+		tagSemaphore <- struct{}{}
 		newResources, err := svc.ResourceFunc(iface, job, region)
+		<-tagSemaphore
 		if err != nil {
 			return nil, err
 		}
 		resources = append(resources, newResources...)
 	}
 	if svc.FilterFunc != nil {
+		tagSemaphore <- struct{}{}
 		resources, err = svc.FilterFunc(iface, resources)
+		<-tagSemaphore
 		if err != nil {
 			return nil, err
 		}
@@ -149,10 +283,50 @@ func (iface tagsInterface) get(job *Job, region string) (resources []*tagsData,
 	return resources, err
 }
 
+func (iface tagsInterface) getResources(job *Job, region string, filter *string) (resources []*tagsData, err error) {
+	var inputparams = r.GetResourcesInput{
+		ResourceTypeFilters: []*string{filter},
+	}
+	c := iface.client
+	ctx := context.Background()
+	pageNum := 0
+
+	err = c.GetResourcesPagesWithContext(ctx, &inputparams, func(page *r.GetResourcesOutput, lastPage bool) bool {
+		pageNum++
+		resourceGroupTaggingAPICounter.Inc()
+
+		if len(page.ResourceTagMappingList) == 0 {
+			log.Debugf("Resource tag list is empty. Tags must be defined for %s to be discovered.", job.Type)
+		}
+
+		for _, resourceTagMapping := range page.ResourceTagMappingList {
+			resource := tagsData{
+				ID:        resourceTagMapping.ResourceARN,
+				Namespace: &job.Type,
+				Region:    &region,
+			}
+
+			for _, t := range resourceTagMapping.Tags {
+				resource.Tags = append(resource.Tags, &Tag{Key: *t.Key, Value: *t.Value})
+			}
+
+			if resource.filterThroughTags(job.SearchTags) {
+				resources = append(resources, &resource)
+			} else {
+				log.Debugf("Skipping resource %s because search tags do not match", *resource.ID)
+			}
+		}
+		return pageNum < 100
+	})
+	return resources, err
+}
+
 func migrateTagsToPrometheus(tagData []*tagsData, labelsSnakeCase bool) []*PrometheusMetric {
 	output := make([]*PrometheusMetric, 0)
 
 	tagList := make(map[string][]string)
+	accountTagList := make(map[string][]string)
+	roleLabelList := make(map[string][]string)
 
 	for _, d := range tagData {
 		for _, entry := range d.Tags {
@@ -160,6 +334,16 @@ func migrateTagsToPrometheus(tagData []*tagsData, labelsSnakeCase bool) []*Prome
 				tagList[*d.Namespace] = append(tagList[*d.Namespace], entry.Key)
 			}
 		}
+		for _, entry := range d.AccountTags {
+			if !stringInSlice(entry.Key, accountTagList[*d.Namespace]) {
+				accountTagList[*d.Namespace] = append(accountTagList[*d.Namespace], entry.Key)
+			}
+		}
+		for label := range d.RoleLabels {
+			if !stringInSlice(label, roleLabelList[*d.Namespace]) {
+				roleLabelList[*d.Namespace] = append(roleLabelList[*d.Namespace], label)
+			}
+		}
 	}
 
 	for _, d := range tagData {
@@ -170,6 +354,9 @@ func migrateTagsToPrometheus(tagData []*tagsData, labelsSnakeCase bool) []*Prome
 		name := promString(promNs) + "_info"
 		promLabels := make(map[string]string)
 		promLabels["name"] = *d.ID
+		for _, label := range roleLabelList[*d.Namespace] {
+			promLabels[label] = d.RoleLabels[label]
+		}
 
 		for _, entry := range tagList[*d.Namespace] {
 			labelKey := "tag_" + promStringTag(entry, labelsSnakeCase)
@@ -182,14 +369,25 @@ func migrateTagsToPrometheus(tagData []*tagsData, labelsSnakeCase bool) []*Prome
 			}
 		}
 
+		for _, entry := range accountTagList[*d.Namespace] {
+			labelKey := "account_tag_" + promStringTag(entry, labelsSnakeCase)
+			promLabels[labelKey] = ""
+
+			for _, aTag := range d.AccountTags {
+				if entry == aTag.Key {
+					promLabels[labelKey] = aTag.Value
+				}
+			}
+		}
+
 		var i int
 		f := float64(i)
 
 		p := PrometheusMetric{
-			name:   &name,
-			labels: promLabels,
-			value:  &f,
+			name:  &name,
+			value: &f,
 		}
+		p.setLabels(promLabels)
 
 		output = append(output, &p)
 	}
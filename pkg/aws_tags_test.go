@@ -1,7 +1,15 @@
 package exporter
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	r "github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
 )
 
 func TestMigrateTagsToPrometheus(t *testing.T) {
@@ -22,10 +30,10 @@ func TestMigrateTagsToPrometheus(t *testing.T) {
 	var metricValue float64 = 0
 
 	p := PrometheusMetric{
-		name:   &prometheusMetricName,
-		labels: promLabels,
-		value:  &metricValue,
+		name:  &prometheusMetricName,
+		value: &metricValue,
 	}
+	p.setLabels(promLabels)
 	expected := []*PrometheusMetric{&p}
 
 	// Act
@@ -37,3 +45,108 @@ func TestMigrateTagsToPrometheus(t *testing.T) {
 	}
 
 }
+
+func TestMigrateTagsToPrometheusRoleLabels(t *testing.T) {
+	namespace := "AWS/Service"
+	tagsData := []*tagsData{
+		{ID: aws.String("production"), Namespace: &namespace, RoleLabels: map[string]string{"environment": "production"}},
+		{ID: aws.String("default"), Namespace: &namespace},
+	}
+
+	actual := migrateTagsToPrometheus(tagsData, false)
+
+	equals(t, map[string]string{"name": "production", "environment": "production"}, labelsOf(actual[0]))
+	equals(t, map[string]string{"name": "default", "environment": ""}, labelsOf(actual[1]))
+}
+
+func TestMigrateTagsToPrometheusAccountTags(t *testing.T) {
+	namespace := "AWS/Service"
+	tagsData := []*tagsData{
+		{ID: aws.String("platform"), Namespace: &namespace, AccountTags: []Tag{{Key: "team", Value: "platform"}}},
+		{ID: aws.String("untagged"), Namespace: &namespace},
+	}
+
+	actual := migrateTagsToPrometheus(tagsData, false)
+
+	equals(t, map[string]string{"name": "platform", "account_tag_team": "platform"}, labelsOf(actual[0]))
+	equals(t, map[string]string{"name": "untagged", "account_tag_team": ""}, labelsOf(actual[1]))
+}
+
+type mockTaggingClient struct {
+	resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
+	resources map[string][]string
+}
+
+func (m mockTaggingClient) GetResourcesPagesWithContext(ctx aws.Context, input *r.GetResourcesInput, fn func(*r.GetResourcesOutput, bool) bool, opts ...request.Option) error {
+	var mappings []*r.ResourceTagMapping
+	for _, arn := range m.resources[*input.ResourceTypeFilters[0]] {
+		mappings = append(mappings, &r.ResourceTagMapping{ResourceARN: aws.String(arn)})
+	}
+	fn(&r.GetResourcesOutput{ResourceTagMappingList: mappings}, true)
+	return nil
+}
+
+func TestTagsInterfaceGetListsResourceFiltersConcurrently(t *testing.T) {
+	iface := tagsInterface{
+		client: mockTaggingClient{
+			resources: map[string][]string{
+				"datasync:task":  {"arn:aws:datasync:us-east-1:123123123123:task/task-1"},
+				"datasync:agent": {"arn:aws:datasync:us-east-1:123123123123:agent/agent-1"},
+			},
+		},
+	}
+	job := &Job{Type: "datasync"}
+
+	resources, err := iface.get(job, "us-east-1", make(chan struct{}, 1))
+
+	equals(t, nil, err)
+	var ids []string
+	for _, resource := range resources {
+		ids = append(ids, *resource.ID)
+	}
+	equals(t, []string{
+		"arn:aws:datasync:us-east-1:123123123123:task/task-1",
+		"arn:aws:datasync:us-east-1:123123123123:agent/agent-1",
+	}, ids)
+}
+
+func TestAddUserAgent(t *testing.T) {
+	client := createTagSession(aws.String("eu-west-1"), Role{}, false, sessionOptions{userAgent: "cluster/production"})
+	req, _ := client.GetResourcesRequest(&r.GetResourcesInput{})
+	if err := req.Build(); err != nil {
+		t.Fatal(err)
+	}
+	equals(t, true, strings.HasSuffix(req.HTTPRequest.Header.Get("User-Agent"), " cluster/production"))
+}
+
+func TestSetEndpoint(t *testing.T) {
+	opts := sessionOptions{endpoints: map[string]string{"tagging": "https://tagging.{region}.proxy.example.com"}}
+
+	config := &aws.Config{Region: aws.String("eu-west-1")}
+	setEndpoint(config, "tagging", opts)
+	equals(t, "https://tagging.eu-west-1.proxy.example.com", aws.StringValue(config.Endpoint))
+
+	config = &aws.Config{Region: aws.String("eu-west-1")}
+	setEndpoint(config, "ec2", opts)
+	equals(t, (*string)(nil), config.Endpoint)
+	equals(t, nil, config.EndpointResolver)
+}
+
+func TestDualStackEndpoints(t *testing.T) {
+	opts := sessionOptions{useDualStack: true}
+
+	equals(t, "https://monitoring.eu-west-1.api.aws", createCloudwatchSession(aws.String("eu-west-1"), Role{}, false, opts, 0).Endpoint)
+	equals(t, "https://monitoring.cn-north-1.api.amazonwebservices.com.cn", createCloudwatchSession(aws.String("cn-north-1"), Role{}, false, opts, 0).Endpoint)
+
+	// Services without a dual-stack endpoint keep their IPv4 endpoint
+	equals(t, "https://tagging.eu-west-1.amazonaws.com", createTagSession(aws.String("eu-west-1"), Role{}, false, opts).Endpoint)
+	equals(t, "https://organizations.us-east-1.amazonaws.com", createOrganizationsSession(Role{}, opts).(*organizations.Organizations).Endpoint)
+
+	// Clients of scrapes without dual-stack endpoints are not affected
+	equals(t, "https://monitoring.eu-west-1.amazonaws.com", createCloudwatchSession(aws.String("eu-west-1"), Role{}, false, sessionOptions{}, 0).Endpoint)
+
+	// Overrides and FIPS endpoints take precedence
+	opts.endpoints = map[string]string{"cloudwatch": "https://monitoring.{region}.proxy.example.com"}
+	equals(t, "https://monitoring.eu-west-1.proxy.example.com", createCloudwatchSession(aws.String("eu-west-1"), Role{}, false, opts, 0).Endpoint)
+	equals(t, "https://ec2-fips.us-east-1.amazonaws.com", createEC2Session(aws.String("us-east-1"), Role{}, true, opts).(*ec2.EC2).Endpoint)
+}
@@ -0,0 +1,217 @@
+package exporter
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// Budget limits the number of metrics requested through GetMetricData per account within a period
+type Budget struct {
+	Metrics int `yaml:"metrics"`
+	Period  int `yaml:"period"`
+}
+
+// getMetricDataBudget tracks the metrics requested per account within the budget period
+var getMetricDataBudget = newBudgetTracker()
+
+type budgetUsage struct {
+	time    time.Time
+	metrics int
+}
+
+// A job skipped again and again waits at most this many scrapes before it asks for budget again
+const maxBudgetDeferral = 64
+
+// budgetDeferral stretches the interval of a job skipped for the budget, it waits twice as many scrapes after every
+// consecutive skip
+type budgetDeferral struct {
+	skips int
+	until int
+}
+
+type budgetTracker struct {
+	mux       sync.Mutex
+	usage     map[string][]budgetUsage
+	exhausted map[string]bool
+	scrape    int
+	deferrals map[string]*budgetDeferral
+}
+
+func newBudgetTracker() *budgetTracker {
+	return &budgetTracker{
+		usage:     make(map[string][]budgetUsage),
+		exhausted: make(map[string]bool),
+		deferrals: make(map[string]*budgetDeferral),
+	}
+}
+
+// startScrape forgets which accounts exhausted their budget in the last scrape
+func (b *budgetTracker) startScrape() {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.exhausted = make(map[string]bool)
+	b.scrape++
+}
+
+// deferred returns whether the job is still waiting out its stretched interval, deferred jobs aren't discovered
+func (b *budgetTracker) deferred(accountId *string, job string) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	d, ok := b.deferrals[job]
+	if !ok || b.scrape >= d.until {
+		return false
+	}
+	b.exhausted[aws.StringValue(accountId)] = true
+	return true
+}
+
+// skipped stretches the interval of a job which didn't fit into the budget
+func (b *budgetTracker) skipped(job string) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	d, ok := b.deferrals[job]
+	if !ok {
+		d = &budgetDeferral{}
+		b.deferrals[job] = d
+	}
+	wait := maxBudgetDeferral
+	if d.skips < 6 {
+		wait = 1 << d.skips
+	}
+	d.skips++
+	d.until = b.scrape + wait
+}
+
+// granted brings a job back to the regular interval
+func (b *budgetTracker) granted(job string) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	delete(b.deferrals, job)
+}
+
+// reserve records the metrics as requested if they fit into the budget of the account, and returns whether they did
+func (b *budgetTracker) reserve(budget *Budget, accountId *string, metrics int, now time.Time) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	account := aws.StringValue(accountId)
+	if _, ok := b.exhausted[account]; !ok {
+		b.exhausted[account] = false
+	}
+
+	since := now.Add(-time.Duration(budget.Period) * time.Second)
+	usage := b.usage[account][:0]
+	used := 0
+	for _, u := range b.usage[account] {
+		if u.time.After(since) {
+			usage = append(usage, u)
+			used += u.metrics
+		}
+	}
+	b.usage[account] = usage
+
+	if used+metrics > budget.Metrics {
+		b.exhausted[account] = true
+		return false
+	}
+	b.usage[account] = append(b.usage[account], budgetUsage{time: now, metrics: metrics})
+	return true
+}
+
+func (b *budgetTracker) publish() {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	budgetExhaustedGauge.Reset()
+	for account, exhausted := range b.exhausted {
+		value := float64(0)
+		if exhausted {
+			value = 1
+		}
+		budgetExhaustedGauge.WithLabelValues(account).Set(value)
+	}
+}
+
+// budgetRequest asks for the budget of the metrics a job requests in a scrape
+type budgetRequest struct {
+	job       string
+	accountId *string
+	priority  int
+	metrics   int
+	granted   bool
+}
+
+// budgetRound grants the budget of a scrape once all role and region goroutines submitted their requests, so the
+// jobs with the highest priority get the budget of an account first, no matter which region they scrape
+type budgetRound struct {
+	tracker  *budgetTracker
+	budget   *Budget
+	mux      sync.Mutex
+	requests []*budgetRequest
+	pending  int
+	done     chan struct{}
+}
+
+// newBudgetRound starts a round waiting for a submit or leave of every participant, a nil budget grants everything
+func newBudgetRound(tracker *budgetTracker, budget *Budget, participants int) *budgetRound {
+	if budget == nil {
+		return nil
+	}
+	r := &budgetRound{
+		tracker: tracker,
+		budget:  budget,
+		pending: participants,
+		done:    make(chan struct{}),
+	}
+	if participants == 0 {
+		close(r.done)
+	}
+	return r
+}
+
+// submit adds the requests of a participant to the round and waits until they are granted or denied
+func (r *budgetRound) submit(requests []*budgetRequest) {
+	if r == nil {
+		for _, request := range requests {
+			request.granted = true
+		}
+		return
+	}
+	r.mux.Lock()
+	r.requests = append(r.requests, requests...)
+	r.mux.Unlock()
+	r.leave()
+	<-r.done
+}
+
+// leave tells the round a participant won't submit requests
+func (r *budgetRound) leave() {
+	if r == nil {
+		return
+	}
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.pending--
+	if r.pending == 0 {
+		r.grant(time.Now())
+		close(r.done)
+	}
+}
+
+func (r *budgetRound) grant(now time.Time) {
+	sort.SliceStable(r.requests, func(a, b int) bool {
+		if r.requests[a].priority != r.requests[b].priority {
+			return r.requests[a].priority > r.requests[b].priority
+		}
+		return r.requests[a].job < r.requests[b].job
+	})
+	for _, request := range r.requests {
+		request.granted = r.tracker.reserve(r.budget, request.accountId, request.metrics, now)
+		if request.granted {
+			r.tracker.granted(request.job)
+		} else {
+			r.tracker.skipped(request.job)
+		}
+	}
+}
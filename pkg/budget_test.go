@@ -0,0 +1,87 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestBudgetTracker(t *testing.T) {
+	budget := &Budget{Metrics: 100, Period: 3600}
+	account := aws.String("123123123123")
+	tracker := newBudgetTracker()
+	now := time.Now()
+
+	tracker.startScrape()
+	equals(t, true, tracker.reserve(budget, account, 60, now))
+	equals(t, false, tracker.reserve(budget, account, 60, now))
+	equals(t, true, tracker.reserve(budget, account, 40, now))
+	tracker.publish()
+	equals(t, float64(1), testutil.ToFloat64(budgetExhaustedGauge.WithLabelValues("123123123123")))
+
+	// The usage of the last period counts against the budget of the next scrapes
+	tracker.startScrape()
+	equals(t, false, tracker.reserve(budget, account, 1, now.Add(30*time.Minute)))
+	equals(t, true, tracker.reserve(budget, account, 100, now.Add(61*time.Minute)))
+	equals(t, true, tracker.reserve(budget, aws.String("456456456456"), 100, now))
+	tracker.publish()
+	equals(t, float64(1), testutil.ToFloat64(budgetExhaustedGauge.WithLabelValues("123123123123")))
+	equals(t, float64(0), testutil.ToFloat64(budgetExhaustedGauge.WithLabelValues("456456456456")))
+}
+
+func TestBudgetRoundAcrossRegions(t *testing.T) {
+	budget := &Budget{Metrics: 100, Period: 3600}
+	account := aws.String("123123123123")
+	tracker := newBudgetTracker()
+	tracker.startScrape()
+	round := newBudgetRound(tracker, budget, 3)
+
+	// The low priority job of one region submits first, it still has to leave the budget to the other region
+	low := &budgetRequest{job: "123123123123/eu-west-1/AWS/EC2", accountId: account, priority: 0, metrics: 60}
+	high := &budgetRequest{job: "123123123123/us-east-1/AWS/SQS", accountId: account, priority: 10, metrics: 60}
+	done := make(chan struct{})
+	go func() {
+		round.submit([]*budgetRequest{low})
+		close(done)
+	}()
+	round.leave()
+	round.submit([]*budgetRequest{high})
+	<-done
+	equals(t, true, high.granted)
+	equals(t, false, low.granted)
+}
+
+func TestBudgetDeferral(t *testing.T) {
+	account := aws.String("123123123123")
+	tracker := newBudgetTracker()
+	job := "123123123123/eu-west-1/AWS/EC2"
+
+	// Every consecutive skip doubles the scrapes the job waits for before asking for budget again
+	tracker.startScrape()
+	tracker.skipped(job)
+	tracker.startScrape()
+	equals(t, false, tracker.deferred(account, job))
+	tracker.skipped(job)
+	tracker.startScrape()
+	equals(t, true, tracker.deferred(account, job))
+	tracker.startScrape()
+	equals(t, false, tracker.deferred(account, job))
+	tracker.skipped(job)
+	for i := 0; i < 3; i++ {
+		tracker.startScrape()
+		equals(t, true, tracker.deferred(account, job))
+	}
+	tracker.startScrape()
+	equals(t, false, tracker.deferred(account, job))
+
+	tracker.granted(job)
+	tracker.startScrape()
+	equals(t, false, tracker.deferred(account, job))
+
+	// A nil round grants all requests
+	request := &budgetRequest{job: job, accountId: account, metrics: 1000}
+	newBudgetRound(tracker, nil, 1).submit([]*budgetRequest{request})
+	equals(t, true, request.granted)
+}
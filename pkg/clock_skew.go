@@ -0,0 +1,61 @@
+package exporter
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	log "github.com/sirupsen/logrus"
+)
+
+// The Date header has a precision of a second and is received after the response latency, smaller differences aren't skew
+const minClockSkew = 2 * time.Second
+
+// clockSkew tracks how far the local clock is behind AWS, so time windows aren't shifted away from the latest datapoints
+var clockSkew = &clockSkewTracker{}
+
+type clockSkewTracker struct {
+	mux  sync.Mutex
+	skew time.Duration
+}
+
+// observe records the skew of the local time a response was received at to its Date header
+func (t *clockSkewTracker) observe(local time.Time, date string) {
+	server, err := http.ParseTime(date)
+	if err != nil {
+		return
+	}
+	skew := server.Sub(local).Truncate(time.Second)
+	if skew > -minClockSkew && skew < minClockSkew {
+		skew = 0
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	if skew != t.skew {
+		log.Infof("The local clock is %s behind AWS, time windows are corrected by it", skew)
+	}
+	t.skew = skew
+}
+
+// now returns the current time of AWS
+func (t *clockSkewTracker) now() time.Time {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return time.Now().Add(t.skew)
+}
+
+func (t *clockSkewTracker) publish() {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	clockSkewGauge.Set(t.skew.Seconds())
+}
+
+// observeClockSkew measures the clock skew on the responses of a client
+func observeClockSkew(handlers *request.Handlers) {
+	handlers.Complete.PushBack(func(r *request.Request) {
+		if r.HTTPResponse != nil {
+			clockSkew.observe(time.Now(), r.HTTPResponse.Header.Get("Date"))
+		}
+	})
+}
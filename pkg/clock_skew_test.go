@@ -0,0 +1,27 @@
+package exporter
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClockSkew(t *testing.T) {
+	tracker := &clockSkewTracker{}
+	local := time.Date(2021, 3, 10, 14, 25, 0, 0, time.UTC)
+
+	tracker.observe(local, local.Add(90*time.Second).Format(http.TimeFormat))
+	equals(t, 90*time.Second, tracker.skew)
+
+	tracker.observe(local, local.Add(-time.Minute).Format(http.TimeFormat))
+	equals(t, -time.Minute, tracker.skew)
+
+	// The Date header isn't precise enough for differences of a second
+	tracker.observe(local.Add(500*time.Millisecond), local.Add(time.Second).Format(http.TimeFormat))
+	equals(t, time.Duration(0), tracker.skew)
+
+	// Responses without a Date header keep the skew
+	tracker.observe(local, local.Add(time.Minute).Format(http.TimeFormat))
+	tracker.observe(local, "")
+	equals(t, time.Minute, tracker.skew)
+}
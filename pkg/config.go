@@ -2,7 +2,13 @@ package exporter
 
 import (
 	"fmt"
-	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	log "github.com/sirupsen/logrus"
@@ -10,29 +16,52 @@ import (
 )
 
 type ScrapeConf struct {
-	Discovery Discovery `yaml:"discovery"`
-	Static    []*Static `yaml:"static"`
+	APIVersion            string            `yaml:"apiVersion"`
+	Discovery             Discovery         `yaml:"discovery"`
+	Static                []*Static         `yaml:"static"`
+	UserAgent             string            `yaml:"userAgent"`
+	Endpoints             map[string]string `yaml:"endpoints"`
+	UseDualStackEndpoints bool              `yaml:"useDualStackEndpoints"`
+	Includes              []string          `yaml:"includes"`
 }
 
 type Discovery struct {
 	ExportedTagsOnMetrics exportedTagsOnMetrics `yaml:"exportedTagsOnMetrics"`
+	ExportedAccountTags   []string              `yaml:"exportedAccountTags"`
+	GetMetricDataBudget   *Budget               `yaml:"getMetricDataBudget"`
+	JobTemplates          map[string]*Job       `yaml:"jobTemplates"`
 	Jobs                  []*Job                `yaml:"jobs"`
 }
 
 type exportedTagsOnMetrics map[string][]string
 
 type Job struct {
-	Regions                []string  `yaml:"regions"`
-	Type                   string    `yaml:"type"`
-	Roles                  []Role    `yaml:"roles"`
-	SearchTags             []Tag     `yaml:"searchTags"`
-	CustomTags             []Tag     `yaml:"customTags"`
-	Metrics                []*Metric `yaml:"metrics"`
-	Length                 int       `yaml:"length"`
-	Delay                  int       `yaml:"delay"`
-	Period                 int       `yaml:"period"`
-	AddCloudwatchTimestamp *bool     `yaml:"addCloudwatchTimestamp"`
-	NilToZero              *bool     `yaml:"nilToZero"`
+	Regions                   []string  `yaml:"regions"`
+	Type                      string    `yaml:"type"`
+	Roles                     []Role    `yaml:"roles"`
+	SearchTags                []Tag     `yaml:"searchTags"`
+	CustomTags                []Tag     `yaml:"customTags"`
+	Metrics                   []*Metric `yaml:"metrics"`
+	Length                    int       `yaml:"length"`
+	Delay                     int       `yaml:"delay"`
+	Period                    int       `yaml:"period"`
+	AddCloudwatchTimestamp    *bool     `yaml:"addCloudwatchTimestamp"`
+	NilToZero                 *bool     `yaml:"nilToZero"`
+	AdjustPeriod              *bool     `yaml:"adjustPeriod"`
+	DimensionNameRequirements []string  `yaml:"dimensionNameRequirements"`
+	MaxResources              int       `yaml:"maxResources"`
+	MaxSeries                 int       `yaml:"maxSeries"`
+	EmptyMetricsThreshold     int       `yaml:"emptyMetricsThreshold"`
+	EmptyMetricsRecheck       int       `yaml:"emptyMetricsRecheck"`
+	Priority                  int       `yaml:"priority"`
+	TagsFallback              bool      `yaml:"tagsFallback"`
+	MaxStale                  int       `yaml:"maxStale"`
+	ZeroPlaceholders          bool      `yaml:"zeroPlaceholders"`
+	ServiceQuotas             []string  `yaml:"serviceQuotas"`
+	Template                  string    `yaml:"template"`
+
+	// fields are the keys set in the YAML of the job, so the fields of a template can be overridden with zero values
+	fields map[string]bool
 }
 
 type Static struct {
@@ -43,21 +72,97 @@ type Static struct {
 	CustomTags []Tag       `yaml:"customTags"`
 	Dimensions []Dimension `yaml:"dimensions"`
 	Metrics    []*Metric   `yaml:"metrics"`
+	MaxStale   int         `yaml:"maxStale"`
 }
 
 type Role struct {
-	RoleArn    string `yaml:"roleArn"`
-	ExternalID string `yaml:"externalId"`
+	RoleArn          string            `yaml:"roleArn"`
+	ExternalID       string            `yaml:"externalId"`
+	CredentialSource string            `yaml:"credentialSource"`
+	Profile          string            `yaml:"profile"`
+	SecretsFile      string            `yaml:"secretsFile"`
+	Labels           map[string]string `yaml:"labels"`
+	Regions          []string          `yaml:"regions"`
+}
+
+// Label names which would collide with the labels of the exported metrics
+var reservedLabelNames = []string{"name", "region", "account_id", "quantile"}
+var reservedLabelPrefixes = []string{"dimension_", "tag_", "custom_tag_", "account_tag_"}
+
+var labelNameRegexp = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+
+// Periods below a minute CloudWatch aggregates metrics published at high resolution in, their datapoints are kept for 3 hours
+var highResolutionPeriods = []int{1, 5, 10, 30}
+
+const highResolutionRetention = 3 * 60 * 60
+
+// CloudWatch rolls datapoints up to longer periods as they age, datapoints of periods finer than a tier's are gone after
+// its retention
+var retentionTiers = []struct {
+	retention int
+	period    int
+}{
+	{retention: highResolutionRetention, period: 1},
+	{retention: 15 * 24 * 60 * 60, period: 60},
+	{retention: 63 * 24 * 60 * 60, period: 300},
+	{retention: 455 * 24 * 60 * 60, period: 3600},
+}
+
+// retainedPeriod returns the finest period CloudWatch still has datapoints of from the given seconds ago, 0 if it has none
+func retainedPeriod(age int) int {
+	for _, tier := range retentionTiers {
+		if age <= tier.retention {
+			return tier.period
+		}
+	}
+	return 0
+}
+
+// isValidPeriod returns whether CloudWatch accepts a period, a high resolution period or a multiple of 60 seconds
+func isValidPeriod(period int) bool {
+	if period >= 60 {
+		return period%60 == 0
+	}
+	for _, p := range highResolutionPeriods {
+		if period == p {
+			return true
+		}
+	}
+	return false
+}
+
+// scrapedRegions returns the regions of the role if it has any, or else the regions of the job
+func (r Role) scrapedRegions(jobRegions []string) []string {
+	if len(r.Regions) > 0 {
+		return r.Regions
+	}
+	return jobRegions
+}
+
+// key identifies a role, roles only differing in their credentials or labels are different roles
+func (r Role) key() string {
+	labels := make([]string, 0, len(r.Labels))
+	for name, value := range r.Labels {
+		labels = append(labels, name+"="+value)
+	}
+	sort.Strings(labels)
+	return strings.Join([]string{r.RoleArn, r.ExternalID, r.CredentialSource, r.Profile, r.SecretsFile, strings.Join(labels, ",")}, "|")
 }
 
 type Metric struct {
-	Name                   string   `yaml:"name"`
-	Statistics             []string `yaml:"statistics"`
-	Period                 int      `yaml:"period"`
-	Length                 int      `yaml:"length"`
-	Delay                  int      `yaml:"delay"`
-	NilToZero              *bool    `yaml:"nilToZero"`
-	AddCloudwatchTimestamp *bool    `yaml:"addCloudwatchTimestamp"`
+	Name                      string        `yaml:"name"`
+	Statistics                []string      `yaml:"statistics"`
+	Period                    int           `yaml:"period"`
+	Length                    int           `yaml:"length"`
+	Delay                     int           `yaml:"delay"`
+	NilToZero                 *bool         `yaml:"nilToZero"`
+	AddCloudwatchTimestamp    *bool         `yaml:"addCloudwatchTimestamp"`
+	AdjustPeriod              *bool         `yaml:"adjustPeriod"`
+	StartOffset               time.Duration `yaml:"startOffset"`
+	EndOffset                 time.Duration `yaml:"endOffset"`
+	DimensionNameRequirements []string      `yaml:"dimensionNameRequirements"`
+	PercentilesAsQuantiles    bool          `yaml:"percentilesAsQuantiles"`
+	ComputeAverage            bool          `yaml:"computeAverage"`
 }
 
 type Dimension struct {
@@ -71,12 +176,12 @@ type Tag struct {
 }
 
 func (c *ScrapeConf) Load(file *string) error {
-	yamlFile, err := ioutil.ReadFile(*file)
+	err := c.load(*file, nil)
 	if err != nil {
 		return err
 	}
-	err = yaml.Unmarshal(yamlFile, c)
-	if err != nil {
+
+	if err := c.Discovery.applyJobTemplates(); err != nil {
 		return err
 	}
 
@@ -99,11 +204,253 @@ func (c *ScrapeConf) Load(file *string) error {
 	return nil
 }
 
+// load reads a config file and the files it includes, included is the chain of files including it
+func (c *ScrapeConf) load(file string, included []string) error {
+	path := file
+	if !isRemoteConfig(file) {
+		var err error
+		if path, err = filepath.Abs(file); err != nil {
+			return err
+		}
+	}
+	if stringInSlice(path, included) {
+		return fmt.Errorf("Include cycle: %s -> %s", strings.Join(included, " -> "), path)
+	}
+	yamlFile, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	yamlFile, err = MigrateConfig(yamlFile)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	err = yaml.Unmarshal(yamlFile, c)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	included = append(included, path)
+	for _, pattern := range c.Includes {
+		matches, err := resolveInclude(path, pattern)
+		if err != nil {
+			return fmt.Errorf("%s: Include %s: %v", path, pattern, err)
+		}
+		for _, match := range matches {
+			fragment := ScrapeConf{}
+			if err := fragment.load(match, included); err != nil {
+				return err
+			}
+			if err := c.merge(fragment); err != nil {
+				return fmt.Errorf("%s: %v", match, err)
+			}
+		}
+	}
+	c.Includes = nil
+	return nil
+}
+
+// resolveInclude returns the files matching an include pattern of a config file, remote includes aren't globbed
+func resolveInclude(file, pattern string) ([]string, error) {
+	if isRemoteConfig(pattern) {
+		return []string{pattern}, nil
+	}
+	if isRemoteConfig(file) {
+		return []string{file[:strings.LastIndex(file, "/")+1] + pattern}, nil
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(file), pattern)
+	}
+	return filepath.Glob(pattern)
+}
+
+// merge adds the jobs, job templates and exported tags of an included config
+func (c *ScrapeConf) merge(fragment ScrapeConf) error {
+	if fragment.UserAgent != "" || fragment.Endpoints != nil || fragment.UseDualStackEndpoints ||
+		fragment.Discovery.ExportedAccountTags != nil || fragment.Discovery.GetMetricDataBudget != nil {
+		return fmt.Errorf("Included configs can only have discovery jobs, jobTemplates, exportedTagsOnMetrics and static jobs")
+	}
+	c.Discovery.Jobs = append(c.Discovery.Jobs, fragment.Discovery.Jobs...)
+	c.Static = append(c.Static, fragment.Static...)
+	for name, template := range fragment.Discovery.JobTemplates {
+		if _, ok := c.Discovery.JobTemplates[name]; ok {
+			return fmt.Errorf("Job template %s is already defined", name)
+		}
+		if c.Discovery.JobTemplates == nil {
+			c.Discovery.JobTemplates = make(map[string]*Job)
+		}
+		c.Discovery.JobTemplates[name] = template
+	}
+	for service, tags := range fragment.Discovery.ExportedTagsOnMetrics {
+		if c.Discovery.ExportedTagsOnMetrics == nil {
+			c.Discovery.ExportedTagsOnMetrics = make(exportedTagsOnMetrics)
+		}
+		for _, tag := range tags {
+			if !stringInSlice(tag, c.Discovery.ExportedTagsOnMetrics[service]) {
+				c.Discovery.ExportedTagsOnMetrics[service] = append(c.Discovery.ExportedTagsOnMetrics[service], tag)
+			}
+		}
+	}
+	return nil
+}
+
+// UnmarshalYAML records the keys set in the YAML of the job along with unmarshalling it
+func (j *Job) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Job
+	if err := unmarshal((*plain)(j)); err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	if err := unmarshal(&fields); err != nil {
+		return err
+	}
+	j.fields = make(map[string]bool, len(fields))
+	for key := range fields {
+		j.fields[key] = true
+	}
+	return nil
+}
+
+// isSet returns whether a field is set in the job, jobs not read from YAML set their non-zero fields
+func (j *Job) isSet(field reflect.StructField, value reflect.Value) bool {
+	if j.fields == nil {
+		return !value.IsZero()
+	}
+	return j.fields[strings.Split(field.Tag.Get("yaml"), ",")[0]]
+}
+
+// applyJobTemplates replaces every job using a template with a copy of the template, overridden by the fields set in the
+// job, including fields set to zero values, e.g. tagsFallback: false
+func (d *Discovery) applyJobTemplates() error {
+	for idx, job := range d.Jobs {
+		if job.Template == "" {
+			continue
+		}
+		template, ok := d.JobTemplates[job.Template]
+		if !ok {
+			return fmt.Errorf("Discovery job [%d]: Template %s is not defined in jobTemplates", idx, job.Template)
+		}
+		if template.Template != "" {
+			return fmt.Errorf("Discovery job [%d]: Template %s must not use a template itself", idx, job.Template)
+		}
+
+		// Every instance gets its own copy, jobs and their metrics are completed with their settings in validation
+		instance := template.copy()
+
+		overrides := reflect.ValueOf(job).Elem()
+		fields := reflect.ValueOf(instance).Elem()
+		for i := 0; i < overrides.NumField(); i++ {
+			field := overrides.Type().Field(i)
+			if field.PkgPath == "" && job.isSet(field, overrides.Field(i)) {
+				fields.Field(i).Set(overrides.Field(i))
+			}
+		}
+		instance.Template = ""
+		instance.fields = nil
+		d.Jobs[idx] = instance
+	}
+	return nil
+}
+
+// copy returns a deep copy of the job, sharing no slices, maps or pointers with it
+func (j *Job) copy() *Job {
+	c := *j
+	c.Regions = copyStrings(j.Regions)
+	if j.Roles != nil {
+		c.Roles = make([]Role, len(j.Roles))
+		for i, role := range j.Roles {
+			c.Roles[i] = role.copy()
+		}
+	}
+	c.SearchTags = copyTags(j.SearchTags)
+	c.CustomTags = copyTags(j.CustomTags)
+	if j.Metrics != nil {
+		c.Metrics = make([]*Metric, len(j.Metrics))
+		for i, metric := range j.Metrics {
+			c.Metrics[i] = metric.copy()
+		}
+	}
+	c.AddCloudwatchTimestamp = copyBool(j.AddCloudwatchTimestamp)
+	c.NilToZero = copyBool(j.NilToZero)
+	c.AdjustPeriod = copyBool(j.AdjustPeriod)
+	c.DimensionNameRequirements = copyStrings(j.DimensionNameRequirements)
+	c.ServiceQuotas = copyStrings(j.ServiceQuotas)
+	return &c
+}
+
+func (m *Metric) copy() *Metric {
+	c := *m
+	c.Statistics = copyStrings(m.Statistics)
+	c.NilToZero = copyBool(m.NilToZero)
+	c.AddCloudwatchTimestamp = copyBool(m.AddCloudwatchTimestamp)
+	c.AdjustPeriod = copyBool(m.AdjustPeriod)
+	c.DimensionNameRequirements = copyStrings(m.DimensionNameRequirements)
+	return &c
+}
+
+func (r Role) copy() Role {
+	if r.Labels != nil {
+		labels := make(map[string]string, len(r.Labels))
+		for name, value := range r.Labels {
+			labels[name] = value
+		}
+		r.Labels = labels
+	}
+	r.Regions = copyStrings(r.Regions)
+	return r
+}
+
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+func copyTags(tags []Tag) []Tag {
+	if tags == nil {
+		return nil
+	}
+	return append([]Tag{}, tags...)
+}
+
+func copyBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	c := *b
+	return &c
+}
+
 func (c *ScrapeConf) Validate() error {
 	if c.Discovery.Jobs == nil && c.Static == nil {
 		return fmt.Errorf("At least 1 Discovery job or 1 Static must be defined")
 	}
 
+	if strings.ContainsAny(c.UserAgent, "\r\n") {
+		return fmt.Errorf("UserAgent must not contain line breaks")
+	}
+
+	for service, endpoint := range c.Endpoints {
+		if !stringInSlice(service, endpointServices) {
+			return fmt.Errorf("Endpoints: Service %s is not one of %s", service, strings.Join(endpointServices, ", "))
+		}
+		if u, err := url.Parse(strings.ReplaceAll(endpoint, "{region}", "region")); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("Endpoints: Endpoint %s of service %s is not a valid URL", endpoint, service)
+		}
+	}
+
+	if budget := c.Discovery.GetMetricDataBudget; budget != nil {
+		if budget.Metrics < 1 {
+			return fmt.Errorf("GetMetricDataBudget: Metrics should be a positive integer")
+		}
+		if budget.Period == 0 {
+			budget.Period = 3600
+		}
+		if budget.Period < 1 {
+			return fmt.Errorf("GetMetricDataBudget: Period should be a positive integer")
+		}
+	}
+
 	if c.Discovery.Jobs != nil {
 		for idx, job := range c.Discovery.Jobs {
 			err := job.validateDiscoveryJob(idx)
@@ -126,8 +473,12 @@ func (c *ScrapeConf) Validate() error {
 }
 
 func (j *Job) validateDiscoveryJob(jobIdx int) error {
+	var svc *serviceFilter
 	if j.Type != "" {
-		if SupportedServices.GetService(j.Type) == nil {
+		if aliases := SupportedServices.namespaceAliases(j.Type); len(aliases) > 1 {
+			return fmt.Errorf("Discovery job [%d]: Namespace %s is shared by several services, use one of their aliases as type: %s", jobIdx, j.Type, strings.Join(aliases, ", "))
+		}
+		if svc = SupportedServices.GetService(j.Type); svc == nil {
 			return fmt.Errorf("Discovery job [%d]: Service is not in known list!: %s", jobIdx, j.Type)
 		}
 	} else {
@@ -141,12 +492,42 @@ func (j *Job) validateDiscoveryJob(jobIdx int) error {
 			}
 		}
 	}
-	if len(j.Regions) == 0 {
+	if svc.PinnedRegion != "" {
+		if len(j.Regions) != 0 && (len(j.Regions) != 1 || j.Regions[0] != svc.PinnedRegion) || rolesHaveRegions(j.Roles) {
+			log.Warningf("Discovery job [%s/%d]: %s metrics are only available in %s, configured regions are ignored", j.Type, jobIdx, svc.Namespace, svc.PinnedRegion)
+		}
+		j.Regions = []string{svc.PinnedRegion}
+		for i := range j.Roles {
+			j.Roles[i].Regions = nil
+		}
+	}
+	if !hasRegions(j.Regions, j.Roles) {
 		return fmt.Errorf("Discovery job [%s/%d]: Regions should not be empty", j.Type, jobIdx)
 	}
 	if len(j.Metrics) == 0 {
 		return fmt.Errorf("Discovery job [%s/%d]: Metrics should not be empty", j.Type, jobIdx)
 	}
+	if j.TagsFallback && svc.DescribeFunc == nil {
+		return fmt.Errorf("Discovery job [%s/%d]: tagsFallback is not supported for %s", j.Type, jobIdx, svc.Namespace)
+	}
+	if len(j.ServiceQuotas) > 0 && svc.Namespace != "AWS/Usage" {
+		return fmt.Errorf("Discovery job [%s/%d]: serviceQuotas is only supported for AWS/Usage", j.Type, jobIdx)
+	}
+	if j.MaxResources < 0 {
+		return fmt.Errorf("Discovery job [%s/%d]: MaxResources should not be negative", j.Type, jobIdx)
+	}
+	if j.MaxSeries < 0 {
+		return fmt.Errorf("Discovery job [%s/%d]: MaxSeries should not be negative", j.Type, jobIdx)
+	}
+	if j.MaxStale < 0 {
+		return fmt.Errorf("Discovery job [%s/%d]: MaxStale should not be negative", j.Type, jobIdx)
+	}
+	if j.EmptyMetricsThreshold < 0 || j.EmptyMetricsRecheck < 0 {
+		return fmt.Errorf("Discovery job [%s/%d]: EmptyMetricsThreshold and EmptyMetricsRecheck should not be negative", j.Type, jobIdx)
+	}
+	if j.EmptyMetricsThreshold > 0 && j.EmptyMetricsRecheck == 0 {
+		j.EmptyMetricsRecheck = 10
+	}
 	for metricIdx, metric := range j.Metrics {
 		err := metric.validateMetric(metricIdx, parent, j)
 		if err != nil {
@@ -172,9 +553,12 @@ func (j *Static) validateStaticJob(jobIdx int) error {
 			}
 		}
 	}
-	if len(j.Regions) == 0 {
+	if !hasRegions(j.Regions, j.Roles) {
 		return fmt.Errorf("Static job [%s/%d]: Regions should not be empty", j.Name, jobIdx)
 	}
+	if j.MaxStale < 0 {
+		return fmt.Errorf("Static job [%s/%d]: MaxStale should not be negative", j.Name, jobIdx)
+	}
 	for metricIdx, metric := range j.Metrics {
 		err := metric.validateMetric(metricIdx, parent, nil)
 		if err != nil {
@@ -185,10 +569,56 @@ func (j *Static) validateStaticJob(jobIdx int) error {
 	return nil
 }
 
+// hasRegions returns whether every role of a job has regions to scrape, either its own or the regions of the job
+func hasRegions(jobRegions []string, roles []Role) bool {
+	if len(roles) == 0 {
+		return len(jobRegions) > 0
+	}
+	for _, role := range roles {
+		if len(role.scrapedRegions(jobRegions)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func rolesHaveRegions(roles []Role) bool {
+	for _, role := range roles {
+		if len(role.Regions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Role) validateRole(roleIdx int, parent string) error {
 	if r.RoleArn == "" && r.ExternalID != "" {
 		return fmt.Errorf("Role [%d] in %v: RoleArn should not be empty", roleIdx, parent)
 	}
+	if r.CredentialSource != "" && !stringInSlice(r.CredentialSource, credentialSources) {
+		return fmt.Errorf("Role [%d] in %v: CredentialSource should be one of %s", roleIdx, parent, strings.Join(credentialSources, ", "))
+	}
+	if (r.Profile != "") != (r.CredentialSource == credentialSourceProfile) {
+		return fmt.Errorf("Role [%d] in %v: Profile should be set with and only with CredentialSource %s", roleIdx, parent, credentialSourceProfile)
+	}
+	if (r.SecretsFile != "") != (r.CredentialSource == credentialSourceSecretsFile) {
+		return fmt.Errorf("Role [%d] in %v: SecretsFile should be set with and only with CredentialSource %s", roleIdx, parent, credentialSourceSecretsFile)
+	}
+	if r.SecretsFile != "" {
+		if _, err := readSecretsFile(r.SecretsFile); err != nil {
+			return fmt.Errorf("Role [%d] in %v: SecretsFile %s: %v", roleIdx, parent, r.SecretsFile, err)
+		}
+	}
+	for name := range r.Labels {
+		if !labelNameRegexp.MatchString(name) || stringInSlice(name, reservedLabelNames) {
+			return fmt.Errorf("Role [%d] in %v: Label %s is not a valid label name", roleIdx, parent, name)
+		}
+		for _, prefix := range reservedLabelPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				return fmt.Errorf("Role [%d] in %v: Label %s must not start with %s", roleIdx, parent, name, prefix)
+			}
+		}
+	}
 
 	return nil
 }
@@ -200,6 +630,14 @@ func (m *Metric) validateMetric(metricIdx int, parent string, discovery *Job) er
 	if len(m.Statistics) == 0 {
 		return fmt.Errorf("Metric [%s/%d] in %v: Statistics should not be empty", m.Name, metricIdx, parent)
 	}
+	if m.StartOffset != 0 || m.EndOffset != 0 {
+		if m.EndOffset < 0 || m.StartOffset <= m.EndOffset {
+			return fmt.Errorf("Metric [%s/%d] in %v: startOffset should be greater than endOffset, which should not be negative", m.Name, metricIdx, parent)
+		}
+	}
+	if m.ComputeAverage && !(stringInSlice("Sum", m.Statistics) && stringInSlice("SampleCount", m.Statistics)) {
+		return fmt.Errorf("Metric [%s/%d] in %v: computeAverage needs the Sum and SampleCount statistics", m.Name, metricIdx, parent)
+	}
 	mPeriod := m.Period
 	if mPeriod == 0 && discovery != nil {
 		if discovery.Period != 0 {
@@ -247,6 +685,42 @@ func (m *Metric) validateMetric(metricIdx int, parent string, discovery *Job) er
 		}
 	}
 
+	mDimensionNameRequirements := m.DimensionNameRequirements
+	if len(mDimensionNameRequirements) == 0 && discovery != nil {
+		mDimensionNameRequirements = discovery.DimensionNameRequirements
+	}
+
+	mAdjustPeriod := m.AdjustPeriod
+	if mAdjustPeriod == nil && discovery != nil {
+		mAdjustPeriod = discovery.AdjustPeriod
+	}
+
+	if !isValidPeriod(mPeriod) {
+		return fmt.Errorf("Metric [%s/%d] in %v: Period %d should be 1, 5, 10, 30 or a multiple of 60", m.Name, metricIdx, parent, mPeriod)
+	}
+	// How far back the metric is queried, offsets take precedence over length and delay
+	age := mLength + mDelay
+	if m.StartOffset != 0 {
+		age = int(m.StartOffset.Seconds())
+	}
+	if mPeriod < 60 && age > highResolutionRetention {
+		return fmt.Errorf("Metric [%s/%d] in %v: the window starting %d seconds ago reaches further back than the %d seconds datapoints of period(%d) are retained",
+			m.Name, metricIdx, parent, age, highResolutionRetention, mPeriod)
+	}
+	if retained := retainedPeriod(age); retained == 0 {
+		log.Warningf("Metric [%s/%d] in %v: the window starting %d seconds ago reaches further back than CloudWatch retains datapoints",
+			m.Name, metricIdx, parent, age)
+	} else if mPeriod%retained != 0 {
+		if aws.BoolValue(mAdjustPeriod) {
+			adjusted := (mPeriod/retained + 1) * retained
+			log.Infof("Metric [%s/%d] in %v: period(%d) is adjusted to %d, CloudWatch only retains datapoints of multiples of %d seconds from %d seconds ago",
+				m.Name, metricIdx, parent, mPeriod, adjusted, retained, age)
+			mPeriod = adjusted
+		} else {
+			log.Warningf("Metric [%s/%d] in %v: CloudWatch only retains datapoints of multiples of %d seconds from %d seconds ago, period(%d) will miss data. Set adjustPeriod to round it up",
+				m.Name, metricIdx, parent, retained, age, mPeriod)
+		}
+	}
 	if mLength < mPeriod {
 		log.Warningf(
 			"Metric [%s/%d] in %v: length(%d) is smaller than period(%d). This can cause that the data requested is not ready and generate data gaps",
@@ -257,6 +731,8 @@ func (m *Metric) validateMetric(metricIdx int, parent string, discovery *Job) er
 	m.Delay = mDelay
 	m.NilToZero = mNilToZero
 	m.AddCloudwatchTimestamp = mAddCloudwatchTimestamp
+	m.DimensionNameRequirements = mDimensionNameRequirements
+	m.AdjustPeriod = mAdjustPeriod
 
 	return nil
 }
@@ -0,0 +1,162 @@
+package exporter
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// The apiVersion of the current config schema
+const currentAPIVersion = "v1"
+
+type configMigration struct {
+	from    string
+	to      string
+	migrate func(config yaml.MapSlice) yaml.MapSlice
+}
+
+// configMigrations upgrade configs one apiVersion at a time, configs without apiVersion predate it
+var configMigrations = []configMigration{
+	{from: "", to: "v1", migrate: migrateToV1},
+}
+
+// MigrateConfig upgrades a config to the current apiVersion, keeping the order of its keys
+func MigrateConfig(yamlFile []byte) ([]byte, error) {
+	var config yaml.MapSlice
+	if err := yaml.Unmarshal(yamlFile, &config); err != nil {
+		return nil, err
+	}
+	config, err := migrateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(config)
+}
+
+func migrateConfig(config yaml.MapSlice) (yaml.MapSlice, error) {
+	version, _ := mapValue(config, "apiVersion").(string)
+	if version == currentAPIVersion {
+		return config, nil
+	}
+	for _, migration := range configMigrations {
+		if migration.from != version {
+			continue
+		}
+		config = migration.migrate(config)
+		version = migration.to
+	}
+	if version != currentAPIVersion {
+		return nil, fmt.Errorf("Unknown apiVersion %s, the current apiVersion is %s", version, currentAPIVersion)
+	}
+	config = deleteMapValue(config, "apiVersion")
+	return append(yaml.MapSlice{{Key: "apiVersion", Value: version}}, config...), nil
+}
+
+// migrateToV1 upgrades the breaking config changes made before apiVersion was introduced
+func migrateToV1(config yaml.MapSlice) yaml.MapSlice {
+	discovery, _ := mapValue(config, "discovery").(yaml.MapSlice)
+	jobs, _ := mapValue(discovery, "jobs").([]interface{})
+	for i, j := range jobs {
+		job, ok := j.(yaml.MapSlice)
+		if !ok {
+			continue
+		}
+		job = migrateRoles(job)
+		if region, ok := mapValue(job, "region").(string); ok {
+			log.Warningf("Migrating config: region %s of a job is moved to regions", region)
+			job = deleteMapValue(job, "region")
+			if mapValue(job, "regions") == nil {
+				job = setMapValue(job, "regions", []interface{}{region})
+			}
+		}
+		if mapValue(job, "type") == "cf" {
+			log.Warning("Migrating config: job type cf is renamed to cloudfront")
+			job = setMapValue(job, "type", "cloudfront")
+		}
+		if searchTags, ok := mapValue(job, "searchTags").([]interface{}); ok {
+			for k, t := range searchTags {
+				if tag, ok := t.(yaml.MapSlice); ok {
+					searchTags[k] = renameMapKeys(tag, map[string]string{"Key": "key", "Value": "value"})
+				}
+			}
+		}
+		jobs[i] = job
+	}
+
+	if tags, ok := mapValue(discovery, "exportedTagsOnMetrics").(yaml.MapSlice); ok {
+		discovery = setMapValue(discovery, "exportedTagsOnMetrics", renameMapKeys(tags, map[string]string{"cf": "cloudfront"}))
+	}
+	if discovery != nil {
+		config = setMapValue(config, "discovery", discovery)
+	}
+
+	statics, _ := mapValue(config, "static").([]interface{})
+	for i, s := range statics {
+		if static, ok := s.(yaml.MapSlice); ok {
+			statics[i] = migrateRoles(static)
+		}
+	}
+	return config
+}
+
+// migrateRoles moves roleArn and roleArns of a job to roles
+func migrateRoles(job yaml.MapSlice) yaml.MapSlice {
+	var roleArns []interface{}
+	if roleArn, ok := mapValue(job, "roleArn").(string); ok {
+		roleArns = append(roleArns, roleArn)
+		job = deleteMapValue(job, "roleArn")
+	}
+	if arns, ok := mapValue(job, "roleArns").([]interface{}); ok {
+		roleArns = append(roleArns, arns...)
+		job = deleteMapValue(job, "roleArns")
+	}
+	if len(roleArns) == 0 {
+		return job
+	}
+	log.Warning("Migrating config: roleArn and roleArns of a job are moved to roles")
+	roles, _ := mapValue(job, "roles").([]interface{})
+	for _, roleArn := range roleArns {
+		roles = append(roles, yaml.MapSlice{{Key: "roleArn", Value: roleArn}})
+	}
+	return setMapValue(job, "roles", roles)
+}
+
+func mapValue(m yaml.MapSlice, key string) interface{} {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
+
+func setMapValue(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
+
+func deleteMapValue(m yaml.MapSlice, key string) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			return append(m[:i], m[i+1:]...)
+		}
+	}
+	return m
+}
+
+func renameMapKeys(m yaml.MapSlice, names map[string]string) yaml.MapSlice {
+	for i, item := range m {
+		if key, ok := item.Key.(string); ok {
+			if name, ok := names[key]; ok {
+				m[i].Key = name
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"testing"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	legacy := `discovery:
+  exportedTagsOnMetrics:
+    cf:
+    - Name
+  jobs:
+  - type: cf
+    region: us-east-1
+    roleArns:
+    - arn:aws:iam::123456789012:role/Prometheus
+    searchTags:
+    - Key: type
+      Value: public
+static:
+- name: asg
+  roleArn: arn:aws:iam::123456789012:role/Prometheus
+`
+	expected := `apiVersion: v1
+discovery:
+  exportedTagsOnMetrics:
+    cloudfront:
+    - Name
+  jobs:
+  - type: cloudfront
+    searchTags:
+    - key: type
+      value: public
+    roles:
+    - roleArn: arn:aws:iam::123456789012:role/Prometheus
+    regions:
+    - us-east-1
+static:
+- name: asg
+  roles:
+  - roleArn: arn:aws:iam::123456789012:role/Prometheus
+`
+
+	migrated, err := MigrateConfig([]byte(legacy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	equals(t, expected, string(migrated))
+
+	// Configs of the current apiVersion are unchanged
+	migrated, err = MigrateConfig(migrated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	equals(t, expected, string(migrated))
+}
+
+func TestMigrateConfigUnknownAPIVersion(t *testing.T) {
+	if _, err := MigrateConfig([]byte("apiVersion: v0\n")); err == nil {
+		t.Fatal("expected an error for an unknown apiVersion")
+	}
+}
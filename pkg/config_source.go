@@ -0,0 +1,171 @@
+package exporter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+const (
+	s3ConfigScheme    = "s3://"
+	ssmConfigScheme   = "ssm://"
+	httpConfigScheme  = "http://"
+	httpsConfigScheme = "https://"
+)
+
+// configAuthorizationFile holds the Authorization header of config requests over HTTP, it's read on every request so
+// rotated credentials are picked up. It's only sent to configAuthorizationHost, the host of the config file, and not
+// to the hosts of included configs.
+var (
+	configAuthorizationFile string
+	configAuthorizationHost string
+)
+
+var configHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// configUserAgent is appended to the User-Agent of the requests for config files in S3 and SSM. The userAgent of the
+// config can't be used since it's only known once the config is read.
+var configUserAgent string
+
+// SetConfigUserAgent sets the User-Agent suffix of the requests for config files in S3 and SSM
+func SetConfigUserAgent(userAgent string) {
+	configUserAgent = userAgent
+}
+
+// SetConfigAuthorizationFile sets the file holding the Authorization header of the config file and the included config
+// files on the same host read over HTTP
+func SetConfigAuthorizationFile(configFile, file string) {
+	configAuthorizationFile = file
+	configAuthorizationHost = ""
+	if u, err := url.Parse(configFile); err == nil {
+		configAuthorizationHost = u.Host
+	}
+}
+
+// authorizationFile returns the file holding the Authorization header of a config file read over HTTP, if any
+func authorizationFile(file string) string {
+	u, err := url.Parse(file)
+	if err != nil || configAuthorizationHost == "" || u.Host != configAuthorizationHost {
+		return ""
+	}
+	return configAuthorizationFile
+}
+
+// isRemoteConfig returns whether a config file is read from a remote source instead of the local filesystem
+func isRemoteConfig(file string) bool {
+	for _, scheme := range []string{s3ConfigScheme, ssmConfigScheme, httpConfigScheme, httpsConfigScheme} {
+		if strings.HasPrefix(file, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// readConfigFile reads a local config file, an S3 object (s3://bucket/key), an SSM parameter (ssm://name) or a URL
+func readConfigFile(file string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(file, httpConfigScheme), strings.HasPrefix(file, httpsConfigScheme):
+		return readHTTPConfig(configHTTPClient, file, authorizationFile(file))
+	case strings.HasPrefix(file, s3ConfigScheme):
+		location := strings.SplitN(strings.TrimPrefix(file, s3ConfigScheme), "/", 2)
+		if len(location) != 2 || location[0] == "" || location[1] == "" {
+			return nil, fmt.Errorf("%s is not a valid S3 location, expected s3://bucket/key", file)
+		}
+		client, err := createS3Session(location[0])
+		if err != nil {
+			return nil, err
+		}
+		return readS3Config(client, location[0], location[1])
+	case strings.HasPrefix(file, ssmConfigScheme):
+		name := strings.TrimPrefix(file, ssmConfigScheme)
+		if name == "" {
+			return nil, fmt.Errorf("%s is not a valid SSM parameter, expected ssm://name", file)
+		}
+		return readSSMConfig(createSSMSession(), name)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// createConfigSourceSession creates a session with the exporter's own credentials and region
+func createConfigSourceSession() *session.Session {
+	sess := session.Must(session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	}))
+	addUserAgent(sess, configUserAgent)
+	return sess
+}
+
+// createS3Session creates an S3 client in the region of the bucket
+func createS3Session(bucket string) (s3iface.S3API, error) {
+	sess := createConfigSourceSession()
+	regionHint := aws.StringValue(sess.Config.Region)
+	if regionHint == "" {
+		regionHint = "us-east-1"
+	}
+	region, err := s3manager.GetBucketRegion(aws.BackgroundContext(), sess, bucket, regionHint)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't get the region of bucket %s: %v", bucket, err)
+	}
+	return s3.New(sess, &aws.Config{Region: aws.String(region)}), nil
+}
+
+func createSSMSession() ssmiface.SSMAPI {
+	return ssm.New(createConfigSourceSession())
+}
+
+func readHTTPConfig(client *http.Client, url, authorizationFile string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if authorizationFile != "" {
+		authorization, err := ioutil.ReadFile(authorizationFile)
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't read the authorization of %s: %v", url, err)
+		}
+		req.Header.Set("Authorization", strings.TrimSpace(string(authorization)))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't get %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Couldn't get %s: %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func readS3Config(client s3iface.S3API, bucket, key string) ([]byte, error) {
+	output, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't get s3://%s/%s: %v", bucket, key, err)
+	}
+	defer output.Body.Close()
+	return ioutil.ReadAll(output.Body)
+}
+
+func readSSMConfig(client ssmiface.SSMAPI, name string) ([]byte, error) {
+	output, err := client.GetParameter(&ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't get SSM parameter %s: %v", name, err)
+	}
+	return []byte(aws.StringValue(output.Parameter.Value)), nil
+}
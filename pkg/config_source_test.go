@@ -0,0 +1,157 @@
+package exporter
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+type mockS3Client struct {
+	s3iface.S3API
+	objects map[string]string
+}
+
+func (m mockS3Client) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	return &s3.GetObjectOutput{
+		Body: ioutil.NopCloser(strings.NewReader(m.objects[*input.Bucket+"/"+*input.Key])),
+	}, nil
+}
+
+type mockSSMClient struct {
+	ssmiface.SSMAPI
+	parameters map[string]string
+}
+
+func (m mockSSMClient) GetParameter(input *ssm.GetParameterInput) (*ssm.GetParameterOutput, error) {
+	return &ssm.GetParameterOutput{
+		Parameter: &ssm.Parameter{Value: aws.String(m.parameters[*input.Name])},
+	}, nil
+}
+
+func TestReadS3Config(t *testing.T) {
+	client := mockS3Client{objects: map[string]string{"configs/yace/config.yml": "apiVersion: v1\n"}}
+	config, err := readS3Config(client, "configs", "yace/config.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	equals(t, "apiVersion: v1\n", string(config))
+}
+
+func TestReadSSMConfig(t *testing.T) {
+	client := mockSSMClient{parameters: map[string]string{"/yace/config": "apiVersion: v1\n"}}
+	config, err := readSSMConfig(client, "/yace/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	equals(t, "apiVersion: v1\n", string(config))
+}
+
+func TestConfigUserAgent(t *testing.T) {
+	SetConfigUserAgent("cluster/production")
+	defer SetConfigUserAgent("")
+
+	client := ssm.New(createConfigSourceSession(), &aws.Config{Region: aws.String("eu-west-1")})
+	req, _ := client.GetParameterRequest(&ssm.GetParameterInput{Name: aws.String("yace")})
+	if err := req.Build(); err != nil {
+		t.Fatal(err)
+	}
+	equals(t, true, strings.HasSuffix(req.HTTPRequest.Header.Get("User-Agent"), " cluster/production"))
+}
+
+func TestResolveInclude(t *testing.T) {
+	matches, err := resolveInclude("s3://configs/yace/config.yml", "jobs.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	equals(t, []string{"s3://configs/yace/jobs.yml"}, matches)
+
+	matches, err = resolveInclude("/etc/yace/config.yml", "ssm://yace-jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	equals(t, []string{"ssm://yace-jobs"}, matches)
+}
+
+func TestReadHTTPConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("apiVersion: v1\n"))
+	}))
+	defer server.Close()
+
+	authorizationFile, err := ioutil.TempFile("", "authorization")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(authorizationFile.Name())
+	_, _ = authorizationFile.WriteString("Bearer secret\n")
+	authorizationFile.Close()
+
+	config, err := readHTTPConfig(server.Client(), server.URL+"/config.yml", authorizationFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	equals(t, "apiVersion: v1\n", string(config))
+
+	if _, err := readHTTPConfig(server.Client(), server.URL+"/config.yml", ""); err == nil {
+		t.Fatal("expected an error without authorization")
+	}
+}
+
+func TestHTTPIncludeAuthorization(t *testing.T) {
+	var includeAuthorization []string
+	includeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		includeAuthorization = append(includeAuthorization, r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`discovery:
+  jobs:
+  - type: sqs
+    regions:
+    - eu-west-1
+    metrics:
+      - name: NumberOfMessagesSent
+        statistics:
+          - Sum
+`))
+	}))
+	defer includeServer.Close()
+	configServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("apiVersion: v1\nincludes:\n  - " + includeServer.URL + "/jobs.yml\n"))
+	}))
+	defer configServer.Close()
+
+	authorizationFile, err := ioutil.TempFile("", "authorization")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(authorizationFile.Name())
+	_, _ = authorizationFile.WriteString("Bearer secret\n")
+	authorizationFile.Close()
+
+	configFile := configServer.URL + "/config.yml"
+	SetConfigAuthorizationFile(configFile, authorizationFile.Name())
+	defer SetConfigAuthorizationFile("", "")
+
+	config := ScrapeConf{}
+	if err := config.Load(&configFile); err != nil {
+		t.Fatal(err)
+	}
+	equals(t, 1, len(config.Discovery.Jobs))
+	// The included config on another host doesn't get the credentials of the config file
+	equals(t, []string{""}, includeAuthorization)
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
 )
 
 func TestConfLoad(t *testing.T) {
@@ -13,6 +16,14 @@ func TestConfLoad(t *testing.T) {
 		{configFile: "config_test.yml"},
 		{configFile: "empty_rolearn.ok.yml"},
 		{configFile: "multiple_roles.ok.yml"},
+		{configFile: "role_labels.ok.yml"},
+		{configFile: "role_regions.ok.yml"},
+		{configFile: "job_templates.ok.yml"},
+		{configFile: "job_template_overrides.ok.yml"},
+		{configFile: "includes.ok.yml"},
+		{configFile: "credential_sources.ok.yml"},
+		{configFile: "high_resolution.ok.yml"},
+		{configFile: "offsets.ok.yml"},
 	}
 	for _, tc := range testCases {
 		config := ScrapeConf{}
@@ -35,6 +46,45 @@ func TestBadConfigs(t *testing.T) {
 		}, {
 			configFile: "externalid_with_empty_rolearn.bad.yml",
 			errorMsg:   "RoleArn should not be empty",
+		}, {
+			configFile: "budget_without_metrics.bad.yml",
+			errorMsg:   "Metrics should be a positive integer",
+		}, {
+			configFile: "unknown_endpoint_service.bad.yml",
+			errorMsg:   "Service lambda is not one of",
+		}, {
+			configFile: "reserved_role_label.bad.yml",
+			errorMsg:   "Label tag_environment must not start with tag_",
+		}, {
+			configFile: "role_without_regions.bad.yml",
+			errorMsg:   "Regions should not be empty",
+		}, {
+			configFile: "undefined_job_template.bad.yml",
+			errorMsg:   "Template ecs is not defined in jobTemplates",
+		}, {
+			configFile: "include_cycle.bad.yml",
+			errorMsg:   "Include cycle",
+		}, {
+			configFile: "profile_without_credential_source.bad.yml",
+			errorMsg:   "Profile should be set with and only with CredentialSource profile",
+		}, {
+			configFile: "service_quotas_without_usage.bad.yml",
+			errorMsg:   "serviceQuotas is only supported for AWS/Usage",
+		}, {
+			configFile: "shared_namespace_type.bad.yml",
+			errorMsg:   "Namespace AWS/Route53 is shared by several services, use one of their aliases as type: route53, route53-healthcheck",
+		}, {
+			configFile: "compute_average_without_sum.bad.yml",
+			errorMsg:   "computeAverage needs the Sum and SampleCount statistics",
+		}, {
+			configFile: "invalid_period.bad.yml",
+			errorMsg:   "Period 90 should be 1, 5, 10, 30 or a multiple of 60",
+		}, {
+			configFile: "high_resolution_retention.bad.yml",
+			errorMsg:   "reaches further back than the 10800 seconds datapoints of period(10) are retained",
+		}, {
+			configFile: "offset_order.bad.yml",
+			errorMsg:   "startOffset should be greater than endOffset",
 		},
 	}
 
@@ -52,3 +102,168 @@ func TestBadConfigs(t *testing.T) {
 		}
 	}
 }
+
+func TestPinnedRegion(t *testing.T) {
+	job := Job{
+		Type:    "route53-healthcheck",
+		Regions: []string{"eu-west-1"},
+		Metrics: []*Metric{{Name: "HealthCheckStatus", Statistics: []string{"Minimum"}}},
+	}
+	if err := job.validateDiscoveryJob(0); err != nil {
+		t.Fatal(err)
+	}
+	equals(t, []string{"us-east-1"}, job.Regions)
+
+	job.Regions = nil
+	if err := job.validateDiscoveryJob(0); err != nil {
+		t.Fatal(err)
+	}
+	equals(t, []string{"us-east-1"}, job.Regions)
+
+	job = Job{
+		Type:    "ga",
+		Regions: []string{"eu-west-1"},
+		Metrics: []*Metric{{Name: "ProcessedBytesIn", Statistics: []string{"Sum"}}},
+	}
+	if err := job.validateDiscoveryJob(0); err != nil {
+		t.Fatal(err)
+	}
+	equals(t, []string{"us-west-2"}, job.Regions)
+}
+
+func TestJobTemplates(t *testing.T) {
+	config := ScrapeConf{}
+	configFile := "testdata/job_templates.ok.yml"
+	if err := config.Load(&configFile); err != nil {
+		t.Fatal(err)
+	}
+
+	equals(t, 2, len(config.Discovery.Jobs))
+	for _, job := range config.Discovery.Jobs {
+		equals(t, "ecs-svc", job.Type)
+		equals(t, "", job.Template)
+		equals(t, 600, job.Metrics[0].Period)
+	}
+	equals(t, []string{"eu-north-1"}, config.Discovery.Jobs[0].Regions)
+	equals(t, []Tag(nil), config.Discovery.Jobs[0].CustomTags)
+	equals(t, []string{"eu-west-1"}, config.Discovery.Jobs[1].Regions)
+	equals(t, []Tag{{Key: "team", Value: "radio"}}, config.Discovery.Jobs[1].CustomTags)
+	if config.Discovery.Jobs[0].Metrics[0] == config.Discovery.Jobs[1].Metrics[0] {
+		t.Fatal("jobs of a template share their metrics")
+	}
+}
+
+func TestJobTemplateZeroOverrides(t *testing.T) {
+	config := ScrapeConf{}
+	configFile := "testdata/job_template_overrides.ok.yml"
+	if err := config.Load(&configFile); err != nil {
+		t.Fatal(err)
+	}
+
+	inherited, overridden := config.Discovery.Jobs[0], config.Discovery.Jobs[1]
+	equals(t, true, inherited.TagsFallback)
+	equals(t, true, inherited.ZeroPlaceholders)
+	equals(t, true, *inherited.NilToZero)
+	equals(t, 600, inherited.MaxStale)
+
+	// Fields set to false or 0 in the job override the template
+	equals(t, false, overridden.TagsFallback)
+	equals(t, false, overridden.ZeroPlaceholders)
+	equals(t, false, *overridden.NilToZero)
+	equals(t, false, *overridden.Metrics[0].NilToZero)
+	equals(t, 0, overridden.MaxStale)
+}
+
+func TestJobTemplatesDeepCopy(t *testing.T) {
+	template := &Job{
+		Type:       "ecs-svc",
+		Regions:    []string{"eu-north-1"},
+		Roles:      []Role{{RoleArn: "arn:aws:iam::123456789012:role/prometheus", Labels: map[string]string{"team": "radio"}}},
+		SearchTags: []Tag{{Key: "env", Value: "production"}},
+		CustomTags: []Tag{{Key: "team", Value: "radio"}},
+		NilToZero:  aws.Bool(false),
+		Metrics:    []*Metric{{Name: "MemoryReservation", Statistics: []string{"Average"}, NilToZero: aws.Bool(false)}},
+	}
+	d := Discovery{
+		JobTemplates: map[string]*Job{"ecs": template},
+		Jobs: []*Job{
+			{Template: "ecs", Period: 60},
+			{Template: "ecs", Period: 300, Regions: []string{"eu-west-1"}},
+		},
+	}
+	if err := d.applyJobTemplates(); err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := d.Jobs[0], d.Jobs[1]
+	equals(t, 60, first.Period)
+	equals(t, 300, second.Period)
+	equals(t, []string{"eu-west-1"}, second.Regions)
+
+	// Changing one instance leaves the other instance and the template alone
+	first.Regions[0] = "us-east-1"
+	first.Roles[0].Labels["team"] = "video"
+	first.SearchTags[0].Value = "staging"
+	first.CustomTags[0].Value = "video"
+	*first.NilToZero = true
+	first.Metrics[0].Statistics[0] = "Sum"
+	*first.Metrics[0].NilToZero = true
+	for _, job := range []*Job{second, template} {
+		equals(t, "radio", job.Roles[0].Labels["team"])
+		equals(t, "production", job.SearchTags[0].Value)
+		equals(t, "radio", job.CustomTags[0].Value)
+		equals(t, false, *job.NilToZero)
+		equals(t, "Average", job.Metrics[0].Statistics[0])
+		equals(t, false, *job.Metrics[0].NilToZero)
+	}
+	equals(t, []string{"eu-north-1"}, template.Regions)
+}
+
+func TestIncludes(t *testing.T) {
+	config := ScrapeConf{}
+	configFile := "testdata/includes.ok.yml"
+	if err := config.Load(&configFile); err != nil {
+		t.Fatal(err)
+	}
+
+	equals(t, 2, len(config.Discovery.Jobs))
+	equals(t, "arn:aws:iam::123456789012:role/prometheus", config.Discovery.Jobs[0].Roles[0].RoleArn)
+	equals(t, "arn:aws:iam::210987654321:role/prometheus", config.Discovery.Jobs[1].Roles[0].RoleArn)
+	equals(t, 1, len(config.Static))
+	equals(t, []string{"team", "service"}, config.Discovery.ExportedTagsOnMetrics["ecs-svc"])
+}
+
+func TestRetainedPeriod(t *testing.T) {
+	equals(t, 1, retainedPeriod(3600))
+	equals(t, 60, retainedPeriod(7*24*60*60))
+	equals(t, 300, retainedPeriod(30*24*60*60))
+	equals(t, 3600, retainedPeriod(90*24*60*60))
+	equals(t, 0, retainedPeriod(500*24*60*60))
+}
+
+func TestAdjustPeriod(t *testing.T) {
+	job := &Job{Type: "ec2", Period: 60, Length: 30 * 24 * 60 * 60}
+	metric := &Metric{Name: "CPUUtilization", Statistics: []string{"Average"}}
+	if err := metric.validateMetric(0, "Discovery job [ec2/0]", job); err != nil {
+		t.Fatal(err)
+	}
+	// Only warned about without adjustPeriod
+	equals(t, 60, metric.Period)
+
+	job.AdjustPeriod = aws.Bool(true)
+	metric = &Metric{Name: "CPUUtilization", Statistics: []string{"Average"}, Period: 120}
+	if err := metric.validateMetric(0, "Discovery job [ec2/0]", job); err != nil {
+		t.Fatal(err)
+	}
+	equals(t, 300, metric.Period)
+}
+
+func TestOffsets(t *testing.T) {
+	config := ScrapeConf{}
+	configFile := "testdata/offsets.ok.yml"
+	if err := config.Load(&configFile); err != nil {
+		t.Fatal(err)
+	}
+	equals(t, 24*time.Hour, config.Discovery.Jobs[0].Metrics[0].StartOffset)
+	equals(t, 23*time.Hour, config.Static[0].Metrics[0].EndOffset)
+}
@@ -0,0 +1,111 @@
+package exporter
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// emptyMetrics remembers the queries which returned no datapoints over the last scrapes
+var emptyMetrics = newEmptyMetricsTracker()
+
+type emptyMetric struct {
+	jobType        string
+	emptyScrapes   int
+	skippedScrapes int
+	lastScrape     int
+}
+
+type emptyMetricsTracker struct {
+	mux        sync.Mutex
+	scrape     int
+	suppressed int
+	metrics    map[string]*emptyMetric
+}
+
+func newEmptyMetricsTracker() *emptyMetricsTracker {
+	return &emptyMetricsTracker{
+		metrics: make(map[string]*emptyMetric),
+	}
+}
+
+func emptyMetricKey(getMetricData cloudwatchData) string {
+	return aws.StringValue(getMetricData.AccountId) + "|" + aws.StringValue(getMetricData.Region) + "|" + seriesKey(getMetricData)
+}
+
+// startScrape starts counting the suppressed queries of a new scrape and forgets queries which weren't made in the last scrape
+func (t *emptyMetricsTracker) startScrape() {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	for key, metric := range t.metrics {
+		if metric.lastScrape < t.scrape {
+			delete(t.metrics, key)
+		}
+	}
+	t.scrape++
+	t.suppressed = 0
+}
+
+// filter drops the queries which didn't return datapoints in the last job.EmptyMetricsThreshold scrapes,
+// except every job.EmptyMetricsRecheck scrapes to find out whether they have data again
+func (t *emptyMetricsTracker) filter(job *Job, getMetricDatas []cloudwatchData) []cloudwatchData {
+	if job.EmptyMetricsThreshold == 0 {
+		return getMetricDatas
+	}
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	filtered := getMetricDatas[:0]
+	for _, getMetricData := range getMetricDatas {
+		key := emptyMetricKey(getMetricData)
+		metric, ok := t.metrics[key]
+		if !ok {
+			metric = &emptyMetric{jobType: job.Type}
+			t.metrics[key] = metric
+		}
+		metric.lastScrape = t.scrape
+		if metric.emptyScrapes >= job.EmptyMetricsThreshold {
+			metric.skippedScrapes++
+			if metric.skippedScrapes <= job.EmptyMetricsRecheck {
+				t.suppressed++
+				continue
+			}
+			metric.skippedScrapes = 0
+		}
+		filtered = append(filtered, getMetricData)
+	}
+	return filtered
+}
+
+// record remembers whether the queries passed through filter returned datapoints
+func (t *emptyMetricsTracker) record(getMetricDatas []*cloudwatchData) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	for _, getMetricData := range getMetricDatas {
+		metric, ok := t.metrics[emptyMetricKey(*getMetricData)]
+		if !ok {
+			continue
+		}
+		if getMetricData.GetMetricDataPoint != nil {
+			metric.emptyScrapes = 0
+		} else {
+			metric.emptyScrapes++
+		}
+	}
+}
+
+// forget drops the queries of a job type, or of all jobs without a type, so they're queried again from the next scrape
+func (t *emptyMetricsTracker) forget(jobType string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	for key, metric := range t.metrics {
+		if jobType == "" || metric.jobType == jobType {
+			delete(t.metrics, key)
+		}
+	}
+}
+
+func (t *emptyMetricsTracker) suppressedQueries() int {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	return t.suppressed
+}
@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestEmptyMetricsTracker(t *testing.T) {
+	job := &Job{Type: "ec2", EmptyMetricsThreshold: 2, EmptyMetricsRecheck: 1}
+	getMetricDatas := []cloudwatchData{
+		{
+			ID:         aws.String("i-1"),
+			Metric:     aws.String("CPUUtilization"),
+			Statistics: []string{"Maximum"},
+			Region:     aws.String("us-east-1"),
+			AccountId:  aws.String("123123123123"),
+		},
+	}
+	tracker := newEmptyMetricsTracker()
+
+	scrape := func(hasData bool) int {
+		tracker.startScrape()
+		queries := tracker.filter(job, append([]cloudwatchData{}, getMetricDatas...))
+		var results []*cloudwatchData
+		for i := range queries {
+			if hasData {
+				queries[i].GetMetricDataPoint = aws.Float64(1)
+			}
+			results = append(results, &queries[i])
+		}
+		tracker.record(results)
+		return len(queries)
+	}
+
+	equals(t, 1, scrape(false))
+	equals(t, 1, scrape(false))
+	// Suppressed after two empty scrapes, queried again after EmptyMetricsRecheck skipped scrapes
+	equals(t, 0, scrape(false))
+	equals(t, 1, tracker.suppressedQueries())
+	equals(t, 1, scrape(true))
+	equals(t, 1, scrape(false))
+	equals(t, 1, scrape(false))
+	equals(t, 0, scrape(false))
+
+	// Rediscovering another job type keeps the suppression, rediscovering the job queries it again
+	tracker.forget("rds")
+	equals(t, 1, len(tracker.metrics))
+	tracker.forget("ec2")
+	equals(t, 1, scrape(false))
+	equals(t, 1, scrape(false))
+	equals(t, 0, scrape(false))
+	tracker.forget("")
+	equals(t, 1, scrape(false))
+}
+
+func TestEmptyMetricsTrackerDisabled(t *testing.T) {
+	tracker := newEmptyMetricsTracker()
+	getMetricDatas := []cloudwatchData{{ID: aws.String("i-1"), Metric: aws.String("CPUUtilization")}}
+
+	equals(t, getMetricDatas, tracker.filter(&Job{Type: "ec2"}, getMetricDatas))
+	equals(t, 0, len(tracker.metrics))
+}
@@ -0,0 +1,101 @@
+package exporter
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	measurementReplacer = strings.NewReplacer(",", `\,`, " ", `\ `)
+	tagReplacer         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+// InfluxHandler serves the metrics of the gatherer in InfluxDB line protocol, every series is a measurement named
+// like the metric with its labels as tags and a float field value
+func InfluxHandler(gatherer prometheus.Gatherer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		families, err := gatherer.Gather()
+		if err != nil {
+			log.Warningf("Couldn't gather metrics: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := writeInflux(w, families); err != nil {
+			log.Warningf("Couldn't write metrics: %v", err)
+		}
+	})
+}
+
+// writeInflux writes the gauges and counters of the families in line protocol. Line protocol has no NaN or infinite
+// floats and can't escape newlines in measurements and tags, those series are left out.
+func writeInflux(w io.Writer, families []*dto.MetricFamily) error {
+	bw := bufio.NewWriter(w)
+	for _, family := range families {
+		if strings.Contains(family.GetName(), "\n") {
+			log.Debugf("Leaving out %s in line protocol, the measurement contains a newline", family.GetName())
+			continue
+		}
+		measurement := measurementReplacer.Replace(family.GetName())
+		for _, metric := range family.Metric {
+			var value float64
+			switch {
+			case metric.Gauge != nil:
+				value = metric.Gauge.GetValue()
+			case metric.Counter != nil:
+				value = metric.Counter.GetValue()
+			case metric.Untyped != nil:
+				value = metric.Untyped.GetValue()
+			default:
+				continue
+			}
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				continue
+			}
+			if hasNewlineLabel(metric) {
+				log.Debugf("Leaving out a series of %s in line protocol, a tag contains a newline", family.GetName())
+				continue
+			}
+
+			bw.WriteString(measurement)
+			// Labels are gathered sorted by name, the order InfluxDB recommends for tags
+			for _, label := range metric.Label {
+				// Tags can't have empty values
+				if label.GetValue() == "" {
+					continue
+				}
+				bw.WriteByte(',')
+				bw.WriteString(tagReplacer.Replace(label.GetName()))
+				bw.WriteByte('=')
+				bw.WriteString(tagReplacer.Replace(label.GetValue()))
+			}
+			bw.WriteString(" value=")
+			bw.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+			if metric.TimestampMs != nil {
+				bw.WriteByte(' ')
+				bw.WriteString(strconv.FormatInt(metric.GetTimestampMs()*1e6, 10))
+			}
+			if _, err := bw.WriteString("\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+func hasNewlineLabel(metric *dto.Metric) bool {
+	for _, label := range metric.Label {
+		if strings.Contains(label.GetName(), "\n") || strings.Contains(label.GetValue(), "\n") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestWriteInflux(t *testing.T) {
+	requests := "aws_elb_request_count_sum"
+	latency := "aws_elb_latency_average"
+	one := float64(1)
+	nan := math.NaN()
+	small := 0.000123
+	metrics := []*PrometheusMetric{
+		{
+			name:        &requests,
+			labelNames:  []string{"name", "region", "tag_team"},
+			labelValues: []string{"lb-1", "eu-west-1", ""},
+			value:       &one,
+		},
+		{
+			name:        &latency,
+			labelNames:  []string{"name", "region"},
+			labelValues: []string{"lb-1", "eu-west-1"},
+			value:       &nan,
+		},
+		{
+			name:        &requests,
+			labelNames:  []string{"name", "region"},
+			labelValues: []string{"lb-3\nnewline", "eu-west-1"},
+			value:       &one,
+		},
+		{
+			name:             &requests,
+			labelNames:       []string{"name", "region", "tag_team"},
+			labelValues:      []string{"lb 2,a=b", "eu-west-1", "core"},
+			value:            &small,
+			includeTimestamp: true,
+			timestamp:        time.Unix(1600000000, 0),
+		},
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewPrometheusCollector(metrics))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual bytes.Buffer
+	if err := writeInflux(&actual, families); err != nil {
+		t.Fatal(err)
+	}
+
+	// The NaN latency, the series with a newline in its name tag and the empty tag are left out
+	expected := `aws_elb_request_count_sum,name=lb\ 2\,a\=b,region=eu-west-1,tag_team=core value=0.000123 1600000000000000000
+aws_elb_request_count_sum,name=lb-1,region=eu-west-1 value=1
+`
+	equals(t, expected, actual.String())
+}
@@ -41,87 +41,243 @@ var (
 		Name: "yace_cloudwatch_ec2api_requests_total",
 		Help: "Help is not implemented yet.",
 	})
+	dmsAPICounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "yace_cloudwatch_dmsapi_requests_total",
+		Help: "Help is not implemented yet.",
+	})
+	mediaPackageAPICounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "yace_cloudwatch_mediapackageapi_requests_total",
+		Help: "Help is not implemented yet.",
+	})
+	shieldAPICounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "yace_cloudwatch_shieldapi_requests_total",
+		Help: "Help is not implemented yet.",
+	})
+	organizationsAPICounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "yace_cloudwatch_organizationsapi_requests_total",
+		Help: "Help is not implemented yet.",
+	})
+	serviceQuotasAPICounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "yace_cloudwatch_servicequotasapi_requests_total",
+		Help: "Help is not implemented yet.",
+	})
+	elbv2APICounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "yace_cloudwatch_elbv2api_requests_total",
+		Help: "Help is not implemented yet.",
+	})
+	rdsAPICounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "yace_cloudwatch_rdsapi_requests_total",
+		Help: "Help is not implemented yet.",
+	})
+	droppedResourcesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "yace_cloudwatch_dropped_resources_total",
+		Help: "Number of discovered resources dropped because of the maxResources limit of a job.",
+	}, []string{"type"})
+	droppedSeriesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "yace_cloudwatch_dropped_series_total",
+		Help: "Number of series dropped because of the maxSeries limit of a job.",
+	}, []string{"type"})
+	estimatedCostGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "yace_cloudwatch_estimated_cost_dollars",
+		Help: "Estimated cost of the CloudWatch API usage of the last scrape in US dollars, based on list prices.",
+	}, []string{"type", "account_id", "api"})
+	budgetExhaustedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "yace_cloudwatch_budget_exhausted",
+		Help: "Whether jobs of the account were skipped in the last scrape because the GetMetricData budget was exhausted.",
+	}, []string{"account_id"})
+	dataAgeGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "yace_cloudwatch_data_age_seconds",
+		Help: "Age of the data of jobs served from the last successful scrape because the last scrape failed.",
+	}, []string{"type", "region"})
+	clockSkewGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "yace_clock_skew_seconds",
+		Help: "Seconds the local clock is behind AWS, measured from the Date header of CloudWatch responses and added to time windows.",
+	})
+	regionUpGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "yace_region_up",
+		Help: "Whether the last scrape of the region succeeded.",
+	}, []string{"region"})
+	suppressedQueriesGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "yace_cloudwatch_suppressed_queries",
+		Help: "Number of queries skipped in the last scrape because they returned no datapoints in previous scrapes.",
+	})
 )
 
 type PrometheusMetric struct {
-	name             *string
-	labels           map[string]string
+	name *string
+	// labelNames are sorted, metrics with the same labels share the slice
+	labelNames       []string
+	labelValues      []string
 	value            *float64
 	includeTimestamp bool
 	timestamp        time.Time
 }
 
+// setLabels sets the labels of the metric sorted by name
+func (m *PrometheusMetric) setLabels(labels map[string]string) {
+	m.labelNames = make([]string, 0, len(labels))
+	for name := range labels {
+		m.labelNames = append(m.labelNames, name)
+	}
+	sort.Strings(m.labelNames)
+	m.labelValues = make([]string, len(m.labelNames))
+	for i, name := range m.labelNames {
+		m.labelValues[i] = labels[name]
+	}
+}
+
 type PrometheusCollector struct {
 	metrics []*PrometheusMetric
+	// descs holds the descriptor of every metric
+	descs []*prometheus.Desc
 }
 
 func NewPrometheusCollector(metrics []*PrometheusMetric) *PrometheusCollector {
+	// Keep the metrics of a family together, the text format requires it when streaming
+	sort.SliceStable(metrics, func(i, j int) bool {
+		return *metrics[i].name < *metrics[j].name
+	})
+	metrics = removeDuplicatedMetrics(metrics)
 	return &PrometheusCollector{
-		metrics: removeDuplicatedMetrics(metrics),
+		metrics: metrics,
+		descs:   createDescs(metrics),
 	}
 }
 
+// Describe doesn't send any descriptors, which makes PrometheusCollector an unchecked collector.
+// The exported metrics are only known when collecting them.
 func (p *PrometheusCollector) Describe(descs chan<- *prometheus.Desc) {
-	for _, metric := range p.metrics {
-		descs <- createDesc(metric)
-	}
 }
 
 func (p *PrometheusCollector) Collect(metrics chan<- prometheus.Metric) {
-	for _, metric := range p.metrics {
-		metrics <- createMetric(metric)
+	for i, metric := range p.metrics {
+		metrics <- createMetric(metric, p.descs[i])
 	}
 }
 
-func createDesc(metric *PrometheusMetric) *prometheus.Desc {
-	return prometheus.NewDesc(
-		*metric.name,
-		"Help is not implemented yet.",
-		nil,
-		metric.labels,
-	)
+// createDescs returns the descriptors of the metrics, metrics with the same name and label names share their descriptor
+func createDescs(metrics []*PrometheusMetric) []*prometheus.Desc {
+	descs := make([]*prometheus.Desc, 0, len(metrics))
+	index := newMetricIndex(len(metrics))
+	for _, metric := range metrics {
+		hash := hashStrings(hashString(fnvOffset, *metric.name), metric.labelNames)
+		i := index.find(hash, func(i int) bool {
+			return *metrics[i].name == *metric.name && equalStrings(metrics[i].labelNames, metric.labelNames)
+		})
+		if i >= 0 {
+			descs = append(descs, descs[i])
+			metric.labelNames = metrics[i].labelNames
+		} else {
+			descs = append(descs, prometheus.NewDesc(*metric.name, "Help is not implemented yet.", metric.labelNames, nil))
+		}
+		index.add(hash)
+	}
+	return descs
 }
 
-func createMetric(metric *PrometheusMetric) prometheus.Metric {
-	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
-		Name:        *metric.name,
-		Help:        "Help is not implemented yet.",
-		ConstLabels: metric.labels,
-	})
-
-	gauge.Set(*metric.value)
+// createMetric creates a const gauge for the metric
+func createMetric(metric *PrometheusMetric, desc *prometheus.Desc) prometheus.Metric {
+	constMetric, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, *metric.value, metric.labelValues...)
+	if err != nil {
+		return prometheus.NewInvalidMetric(desc, err)
+	}
 
 	if !metric.includeTimestamp {
-		return gauge
+		return constMetric
 	}
 
-	return prometheus.NewMetricWithTimestamp(metric.timestamp, gauge)
+	return prometheus.NewMetricWithTimestamp(metric.timestamp, constMetric)
 }
 
+// removeDuplicatedMetrics keeps the first of the metrics with the same name and labels
 func removeDuplicatedMetrics(metrics []*PrometheusMetric) []*PrometheusMetric {
-	keys := make(map[string]bool)
-	filteredMetrics := []*PrometheusMetric{}
+	filteredMetrics := make([]*PrometheusMetric, 0, len(metrics))
+	index := newMetricIndex(len(metrics))
 	for _, metric := range metrics {
-		check := *metric.name + combineLabels(metric.labels)
-		if _, value := keys[check]; !value {
-			keys[check] = true
+		hash := hashStrings(hashStrings(hashString(fnvOffset, *metric.name), metric.labelNames), metric.labelValues)
+		duplicate := index.find(hash, func(i int) bool {
+			previous := filteredMetrics[i]
+			return *previous.name == *metric.name && equalStrings(previous.labelValues, metric.labelValues) && equalStrings(previous.labelNames, metric.labelNames)
+		})
+		if duplicate < 0 {
 			filteredMetrics = append(filteredMetrics, metric)
+			index.add(hash)
 		}
 	}
 	return filteredMetrics
 }
 
-func combineLabels(labels map[string]string) string {
-	var combinedLabels string
-	keys := make([]string, 0, len(labels))
-	for k := range labels {
-		keys = append(keys, k)
+// metricIndex finds the metrics added before by the hash of their name and labels, without building keys of them.
+// Metrics are numbered in the order they are added.
+type metricIndex struct {
+	first map[uint64]int
+	// next chains the metrics with the same hash
+	next []int
+}
+
+func newMetricIndex(size int) *metricIndex {
+	return &metricIndex{
+		first: make(map[uint64]int, size),
+		next:  make([]int, 0, size),
+	}
+}
+
+// find returns the first metric with the hash the match function accepts, or -1
+func (x *metricIndex) find(hash uint64, match func(int) bool) int {
+	i, ok := x.first[hash]
+	if !ok {
+		return -1
+	}
+	for ; i >= 0; i = x.next[i] {
+		if match(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (x *metricIndex) add(hash uint64) {
+	next, ok := x.first[hash]
+	if !ok {
+		next = -1
 	}
-	sort.Strings(keys)
-	for _, k := range keys {
-		combinedLabels += promString(k) + promString(labels[k])
+	x.first[hash] = len(x.next)
+	x.next = append(x.next, next)
+}
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// hashString adds the string to the FNV-1a hash, terminated so the boundaries between strings count
+func hashString(hash uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		hash ^= uint64(s[i])
+		hash *= fnvPrime
+	}
+	hash ^= 0xff
+	hash *= fnvPrime
+	return hash
+}
+
+func hashStrings(hash uint64, strings []string) uint64 {
+	for _, s := range strings {
+		hash = hashString(hash, s)
 	}
-	return combinedLabels
+	return hash
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
 func promString(text string) string {
@@ -156,7 +312,8 @@ func sanitize(text string) string {
 	return replacer.Replace(text)
 }
 
+var splitRegexp = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
 func splitString(text string) string {
-	splitRegexp := regexp.MustCompile(`([a-z0-9])([A-Z])`)
 	return splitRegexp.ReplaceAllString(text, `$1.$2`)
 }
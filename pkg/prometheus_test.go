@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestPrometheusCollector(t *testing.T) {
+	name := "aws_elb_request_count_sum"
+	first := float64(1)
+	second := float64(2)
+	timestamp := time.Unix(1600000000, 0)
+	metrics := []*PrometheusMetric{
+		{
+			name:        &name,
+			labelNames:  []string{"name", "region"},
+			labelValues: []string{"lb-1", "eu-west-1"},
+			value:       &first,
+		},
+		{
+			name:             &name,
+			labelNames:       []string{"name", "region"},
+			labelValues:      []string{"lb-2", "eu-west-1"},
+			value:            &second,
+			includeTimestamp: true,
+			timestamp:        timestamp,
+		},
+		{
+			name:        &name,
+			labelNames:  []string{"name", "region"},
+			labelValues: []string{"lb-1", "eu-west-1"},
+			value:       &second,
+		},
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewPrometheusCollector(metrics))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	equals(t, 1, len(families))
+	equals(t, name, families[0].GetName())
+	gathered := families[0].GetMetric()
+	equals(t, 2, len(gathered))
+
+	equals(t, "name", gathered[0].GetLabel()[0].GetName())
+	equals(t, "lb-1", gathered[0].GetLabel()[0].GetValue())
+	equals(t, "region", gathered[0].GetLabel()[1].GetName())
+	equals(t, first, gathered[0].GetGauge().GetValue())
+	equals(t, int64(0), gathered[0].GetTimestampMs())
+
+	equals(t, "lb-2", gathered[1].GetLabel()[0].GetValue())
+	equals(t, second, gathered[1].GetGauge().GetValue())
+	equals(t, timestamp.UnixNano()/int64(time.Millisecond), gathered[1].GetTimestampMs())
+}
+
+func BenchmarkPrometheusCollector(b *testing.B) {
+	names := []string{"aws_elb_request_count_sum", "aws_elb_latency_average"}
+	metrics := make([]*PrometheusMetric, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		value := float64(i)
+		metric := &PrometheusMetric{name: &names[i%2], value: &value}
+		metric.setLabels(map[string]string{"name": fmt.Sprintf("lb-%d", i), "region": "eu-west-1", "account_id": "123123123123",
+			"dimension_load_balancer_name": fmt.Sprintf("lb-%d", i), "tag_team": "core", "tag_env": "production"})
+		metrics = append(metrics, metric)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		collected := make(chan prometheus.Metric, len(metrics))
+		NewPrometheusCollector(metrics).Collect(collected)
+	}
+}
+
+// labelsOf returns the labels of the metric by name
+func labelsOf(metric *PrometheusMetric) map[string]string {
+	labels := make(map[string]string, len(metric.labelNames))
+	for i, name := range metric.labelNames {
+		labels[name] = metric.labelValues[i]
+	}
+	return labels
+}
@@ -0,0 +1,140 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	log "github.com/sirupsen/logrus"
+)
+
+// Error codes meaning the region can't be reached or isn't enabled for the account, denied or throttled requests only
+// fail the job they belong to
+var regionFailureCodes = []string{
+	request.ErrCodeRequestError,
+	request.ErrCodeResponseTimeout,
+	"UnrecognizedClientException",
+	"InvalidClientTokenId",
+	"OptInRequired",
+}
+
+// The error code of requests not sent because their region is down for the role
+const regionDownCode = "RegionDown"
+
+// regions tracks which regions failed for which role in the current scrape, so the remaining requests of the role to
+// them can be skipped. A region failing for one role, e.g. by a service control policy of its account, is still scraped
+// with the other roles.
+var regions = newRegionStatus()
+
+type regionKey struct {
+	role   string
+	region string
+}
+
+type regionStatus struct {
+	mux    sync.Mutex
+	failed map[regionKey]bool
+}
+
+func newRegionStatus() *regionStatus {
+	return &regionStatus{
+		failed: make(map[regionKey]bool),
+	}
+}
+
+func (s *regionStatus) startScrape() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.failed = make(map[regionKey]bool)
+}
+
+// scraped marks a region as scraped with a role, it's up unless it fails
+func (s *regionStatus) scraped(role Role, region string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	key := regionKey{role: role.key(), region: region}
+	if _, ok := s.failed[key]; !ok {
+		s.failed[key] = false
+	}
+}
+
+// report logs the error of a job, errors of unreachable or not enabled regions mark the region as down for the role for
+// the rest of the scrape
+func (s *regionStatus) report(role Role, region string, err error) {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == regionDownCode {
+		return
+	}
+	if !isRegionFailure(err) {
+		log.Warning(err)
+		return
+	}
+	s.fail(role, region, err)
+}
+
+// probeFailed marks the region as down for the role, getting the account id through STS in the region failed
+func (s *regionStatus) probeFailed(role Role, region string, err error) {
+	s.fail(role, region, err)
+}
+
+func isRegionFailure(err error) bool {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return false
+	}
+	for _, code := range regionFailureCodes {
+		if awsErr.Code() == code {
+			return true
+		}
+	}
+	return false
+}
+
+// fail marks a region as down for the role for the rest of the scrape, only the first error of a role and region is logged
+func (s *regionStatus) fail(role Role, region string, err error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	key := regionKey{role: role.key(), region: region}
+	if s.failed[key] {
+		return
+	}
+	s.failed[key] = true
+	log.Warningf("Skipping region %s for role %s for the rest of this scrape: %v", region, role.RoleArn, err)
+}
+
+// skipDownRegion fails the requests of a client to a region which is down for the role without sending or retrying them
+func skipDownRegion(handlers *request.Handlers, role Role, region string) {
+	handlers.Sign.PushBack(func(r *request.Request) {
+		if regions.isDown(role, region) {
+			r.Error = awserr.New(regionDownCode, fmt.Sprintf("region %s is down for role %s", region, role.RoleArn), nil)
+		}
+	})
+}
+
+func (s *regionStatus) isDown(role Role, region string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.failed[regionKey{role: role.key(), region: region}]
+}
+
+// publish sets a region down if it failed for any of the roles it was scraped with
+func (s *regionStatus) publish() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	up := make(map[string]bool)
+	for key, failed := range s.failed {
+		if previous, ok := up[key.region]; !ok || previous {
+			up[key.region] = !failed
+		}
+	}
+	regionUpGauge.Reset()
+	for region, regionUp := range up {
+		value := float64(0)
+		if regionUp {
+			value = 1
+		}
+		regionUpGauge.WithLabelValues(region).Set(value)
+	}
+}
@@ -0,0 +1,103 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRegionStatus(t *testing.T) {
+	status := newRegionStatus()
+	role := Role{}
+	unreachable := awserr.New(request.ErrCodeRequestError, "send request failed", nil)
+
+	status.startScrape()
+	status.scraped(role, "eu-west-1")
+	status.scraped(role, "us-east-1")
+	status.report(role, "eu-west-1", errors.New("Unable to get metric data"))
+	status.report(role, "us-east-1", fmt.Errorf("Unable to list metrics due to %w", unreachable))
+	status.report(role, "us-east-1", unreachable)
+	equals(t, false, status.isDown(role, "eu-west-1"))
+	equals(t, true, status.isDown(role, "us-east-1"))
+
+	status.publish()
+	equals(t, float64(1), testutil.ToFloat64(regionUpGauge.WithLabelValues("eu-west-1")))
+	equals(t, float64(0), testutil.ToFloat64(regionUpGauge.WithLabelValues("us-east-1")))
+
+	// A region is only skipped for the scrape it failed in
+	status.startScrape()
+	status.scraped(role, "us-east-1")
+	equals(t, false, status.isDown(role, "us-east-1"))
+}
+
+func TestRegionStatusPerRole(t *testing.T) {
+	status := newRegionStatus()
+	denied := Role{RoleArn: "arn:aws:iam::123456789012:role/denied"}
+	allowed := Role{RoleArn: "arn:aws:iam::210987654321:role/allowed"}
+
+	status.startScrape()
+	status.scraped(denied, "eu-west-1")
+	status.scraped(allowed, "eu-west-1")
+	status.probeFailed(denied, "eu-west-1", awserr.New("AccessDenied", "denied by service control policy", nil))
+
+	// The other role still scrapes the region
+	equals(t, true, status.isDown(denied, "eu-west-1"))
+	equals(t, false, status.isDown(allowed, "eu-west-1"))
+
+	status.publish()
+	equals(t, float64(0), testutil.ToFloat64(regionUpGauge.WithLabelValues("eu-west-1")))
+}
+
+func TestRegionStatusJobErrors(t *testing.T) {
+	status := newRegionStatus()
+	role := Role{}
+
+	// Denied or throttled requests of a job leave the region up for the other jobs
+	status.startScrape()
+	status.scraped(role, "eu-west-1")
+	status.report(role, "eu-west-1", awserr.New("AccessDeniedException", "not authorized to perform shield:ListProtections", nil))
+	status.report(role, "eu-west-1", awserr.New("Throttling", "rate exceeded", nil))
+	equals(t, false, status.isDown(role, "eu-west-1"))
+
+	status.report(role, "eu-west-1", awserr.New("OptInRequired", "region not enabled", nil))
+	equals(t, true, status.isDown(role, "eu-west-1"))
+}
+
+func TestSkipDownRegion(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	role := Role{}
+	client := cloudwatch.New(session.Must(session.NewSession()), &aws.Config{
+		Region:      aws.String("eu-west-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+	})
+	skipDownRegion(&client.Handlers, role, "eu-west-1")
+
+	regions.startScrape()
+	regions.scraped(role, "eu-west-1")
+	regions.probeFailed(role, "eu-west-1", errors.New("Couldn't get account Id"))
+	_, err := client.ListMetrics(&cloudwatch.ListMetricsInput{})
+
+	// The request is neither sent nor retried
+	var awsErr awserr.Error
+	equals(t, true, errors.As(err, &awsErr))
+	equals(t, regionDownCode, awsErr.Code())
+	equals(t, 0, requests)
+	regions.startScrape()
+}
@@ -8,7 +8,10 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/apigateway"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/databasemigrationservice"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/mediapackage"
+	"github.com/aws/aws-sdk-go/service/shield"
 )
 
 type ResourceFunc func(tagsInterface, *Job, string) ([]*tagsData, error)
@@ -19,23 +22,42 @@ type serviceFilter struct {
 	Namespace        string
 	Alias            string
 	IgnoreLength     bool
+	PinnedRegion     string
 	ResourceFilters  []*string
 	DimensionRegexps []*string
 	ResourceFunc     ResourceFunc
 	FilterFunc       FilterFunc
+	// DescribeFunc lists the resources with their tags through the service's own API, for jobs with tagsFallback
+	DescribeFunc ResourceFunc
 }
 
 type serviceConfig []serviceFilter
 
+// GetService returns the service with the alias or namespace, a namespace shared by several services only matches
+// through their aliases
 func (sc serviceConfig) GetService(serviceType string) *serviceFilter {
 	for _, sf := range sc {
-		if sf.Alias == serviceType || sf.Namespace == serviceType {
+		if sf.Alias == serviceType {
 			return &sf
 		}
 	}
+	if aliases := sc.namespaceAliases(serviceType); len(aliases) == 1 {
+		return sc.GetService(aliases[0])
+	}
 	return nil
 }
 
+// namespaceAliases returns the aliases of the services with the namespace
+func (sc serviceConfig) namespaceAliases(namespace string) []string {
+	var aliases []string
+	for _, sf := range sc {
+		if sf.Namespace == namespace {
+			aliases = append(aliases, sf.Alias)
+		}
+	}
+	return aliases
+}
+
 var (
 	SupportedServices = serviceConfig{
 		{
@@ -44,8 +66,38 @@ var (
 			ResourceFilters: []*string{
 				aws.String("acm:certificate"),
 			},
+			DimensionRegexps: []*string{
+				aws.String("(?P<CertificateArn>.*)"),
+			},
 		},
 		{
+			Namespace: "AmazonMWAA",
+			Alias:     "mwaa",
+			ResourceFilters: []*string{
+				aws.String("airflow"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":environment/(?P<Environment>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/AmplifyHosting",
+			Alias:     "amplify",
+			ResourceFilters: []*string{
+				aws.String("amplify"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":apps/(?P<App>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/AppFlow",
+			Alias:     "appflow",
+			ResourceFilters: []*string{
+				aws.String("appflow:flow"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":flow/(?P<FlowName>[^/]+)$"),
+			},
+		}, {
 			Namespace: "AWS/ApplicationELB",
 			Alias:     "alb",
 			ResourceFilters: []*string{
@@ -56,6 +108,7 @@ var (
 				aws.String(":(?P<TargetGroup>targetgroup/.+)"),
 				aws.String(":loadbalancer/(?P<LoadBalancer>.+)$"),
 			},
+			DescribeFunc: describeLoadBalancers("application"),
 		}, {
 			Namespace: "AWS/ApiGateway",
 			Alias:     "apigateway",
@@ -100,6 +153,27 @@ var (
 			ResourceFilters: []*string{
 				aws.String("mq"),
 			},
+			DimensionRegexps: []*string{
+				aws.String("broker:(?P<Broker>[^:]+)"),
+			},
+		}, {
+			Namespace: "AWS/AppRunner",
+			Alias:     "apprunner",
+			ResourceFilters: []*string{
+				aws.String("apprunner:service"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":service/(?P<ServiceName>[^/]+)/(?P<ServiceID>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/AppStream",
+			Alias:     "appstream",
+			ResourceFilters: []*string{
+				aws.String("appstream:fleet"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":fleet/(?P<Fleet>[^/]+)$"),
+			},
 		}, {
 			Namespace: "AWS/AppSync",
 			Alias:     "appsync",
@@ -113,10 +187,10 @@ var (
 			Namespace: "AWS/Athena",
 			Alias:     "athena",
 			ResourceFilters: []*string{
-				aws.String("athena"),
+				aws.String("athena:workgroup"),
 			},
 			DimensionRegexps: []*string{
-				aws.String("athena/(?P<WorkGroup>[^/]+)"),
+				aws.String("workgroup/(?P<WorkGroup>[^/]+)"),
 			},
 		},
 		{
@@ -152,16 +226,47 @@ var (
 					},
 				)
 			},
+		}, {
+			Namespace: "AWS/Backup",
+			Alias:     "backup",
+			ResourceFilters: []*string{
+				aws.String("backup:backup-vault"),
+			},
+			DimensionRegexps: []*string{
+				aws.String("backup-vault:(?P<BackupVaultName>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/Batch",
+			Alias:     "batch",
+			ResourceFilters: []*string{
+				aws.String("batch:job-queue"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":job-queue/(?P<JobQueue>[^/]+)$"),
+			},
 		}, {
 			Namespace:    "AWS/Billing",
 			Alias:        "billing",
 			IgnoreLength: true,
+			PinnedRegion: "us-east-1",
 		}, {
 			Namespace: "AWS/Cassandra",
 			Alias:     "cassandra",
 			ResourceFilters: []*string{
 				aws.String("cassandra"),
 			},
+			DimensionRegexps: []*string{
+				aws.String("keyspace/(?P<Keyspace>[^/]+)/table/(?P<TableName>[^/]+)"),
+			},
+		}, {
+			Namespace: "AWS/ClientVPN",
+			Alias:     "clientvpn",
+			ResourceFilters: []*string{
+				aws.String("ec2:client-vpn-endpoint"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":client-vpn-endpoint/(?P<Endpoint>[^/]+)$"),
+			},
 		}, {
 			Namespace: "AWS/CloudFront",
 			Alias:     "cloudfront",
@@ -171,6 +276,24 @@ var (
 			DimensionRegexps: []*string{
 				aws.String("distribution/(?P<DistributionId>[^/]+)"),
 			},
+		}, {
+			Namespace: "AWS/CloudHSM",
+			Alias:     "cloudhsm",
+			ResourceFilters: []*string{
+				aws.String("cloudhsm:cluster"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":cluster/(?P<ClusterId>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/CodeBuild",
+			Alias:     "codebuild",
+			ResourceFilters: []*string{
+				aws.String("codebuild"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":project/(?P<ProjectName>[^/]+)$"),
+			},
 		}, {
 			Namespace: "AWS/Cognito",
 			Alias:     "cognito-idp",
@@ -180,12 +303,117 @@ var (
 			DimensionRegexps: []*string{
 				aws.String("userpool/(?P<UserPool>[^/]+)"),
 			},
+		}, {
+			Namespace: "AWS/Connect",
+			Alias:     "connect",
+			ResourceFilters: []*string{
+				aws.String("connect"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":instance/(?P<InstanceId>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/DataSync",
+			Alias:     "datasync",
+			ResourceFilters: []*string{
+				aws.String("datasync:task"),
+				aws.String("datasync:agent"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":task/(?P<TaskId>[^/]+)$"),
+				aws.String(":agent/(?P<AgentId>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/DAX",
+			Alias:     "dax",
+			ResourceFilters: []*string{
+				aws.String("dax"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":cache/(?P<ClusterId>[^/]+)$"),
+			},
 		}, {
 			Namespace: "AWS/DDoSProtection",
 			Alias:     "shield",
 			ResourceFilters: []*string{
 				aws.String("shield:protection"),
 			},
+			DimensionRegexps: []*string{
+				aws.String("(?P<ResourceArn>.+)"),
+			},
+			FilterFunc: func(iface tagsInterface, inputResources []*tagsData) (outputResources []*tagsData, err error) {
+				ctx := context.Background()
+				// Metrics are reported per protected resource, so the protection ARN is replaced by the ARN of the resource it protects
+				var protections []*shield.Protection
+				err = iface.shieldClient.ListProtectionsPagesWithContext(ctx, &shield.ListProtectionsInput{},
+					func(page *shield.ListProtectionsOutput, lastPage bool) bool {
+						shieldAPICounter.Inc()
+						protections = append(protections, page.Protections...)
+						return true
+					})
+				if err != nil {
+					return nil, err
+				}
+				for _, resource := range inputResources {
+					for _, protection := range protections {
+						if strings.HasSuffix(*resource.ID, "/"+*protection.Id) {
+							r := resource
+							r.ID = protection.ResourceArn
+							outputResources = append(outputResources, r)
+							break
+						}
+					}
+				}
+				return outputResources, nil
+			},
+		}, {
+			Namespace: "AWS/DMS",
+			Alias:     "dms",
+			ResourceFilters: []*string{
+				aws.String("dms"),
+			},
+			DimensionRegexps: []*string{
+				aws.String("task:(?P<ReplicationTaskIdentifier>[^/]+)/(?P<ReplicationInstanceIdentifier>[^/]+)"),
+				aws.String("rep:[^/]+/(?P<ReplicationInstanceIdentifier>[^/]+)"),
+			},
+			FilterFunc: func(iface tagsInterface, inputResources []*tagsData) (outputResources []*tagsData, err error) {
+				ctx := context.Background()
+				// Replication instance metrics are reported under the instance identifier instead of the
+				// resource id used in the ARN, so the identifier is appended to instance and task ARNs
+				replicationInstanceIdentifiers := make(map[string]string)
+				err = iface.dmsClient.DescribeReplicationInstancesPagesWithContext(ctx, &databasemigrationservice.DescribeReplicationInstancesInput{},
+					func(page *databasemigrationservice.DescribeReplicationInstancesOutput, lastPage bool) bool {
+						dmsAPICounter.Inc()
+						for _, instance := range page.ReplicationInstances {
+							replicationInstanceIdentifiers[*instance.ReplicationInstanceArn] = *instance.ReplicationInstanceIdentifier
+						}
+						return true
+					})
+				if err != nil {
+					return nil, err
+				}
+				err = iface.dmsClient.DescribeReplicationTasksPagesWithContext(ctx, &databasemigrationservice.DescribeReplicationTasksInput{WithoutSettings: aws.Bool(true)},
+					func(page *databasemigrationservice.DescribeReplicationTasksOutput, lastPage bool) bool {
+						dmsAPICounter.Inc()
+						for _, task := range page.ReplicationTasks {
+							if instanceIdentifier, ok := replicationInstanceIdentifiers[*task.ReplicationInstanceArn]; ok {
+								replicationInstanceIdentifiers[*task.ReplicationTaskArn] = instanceIdentifier
+							}
+						}
+						return true
+					})
+				if err != nil {
+					return nil, err
+				}
+				for _, resource := range inputResources {
+					r := resource
+					if instanceIdentifier, ok := replicationInstanceIdentifiers[*resource.ID]; ok {
+						r.ID = aws.String(fmt.Sprintf("%s/%s", *resource.ID, instanceIdentifier))
+					}
+					outputResources = append(outputResources, r)
+				}
+				return outputResources, nil
+			},
 		}, {
 			Namespace: "AWS/DocDB",
 			Alias:     "docdb",
@@ -197,6 +425,16 @@ var (
 				aws.String("cluster:(?P<DBClusterIdentifier>[^/]+)"),
 				aws.String("db:(?P<DBInstanceIdentifier>[^/]+)"),
 			},
+		}, {
+			Namespace: "AWS/DX",
+			Alias:     "dx",
+			ResourceFilters: []*string{
+				aws.String("directconnect"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":dxcon/(?P<ConnectionId>[^/]+)"),
+				aws.String(":dxvif/(?P<VirtualInterfaceId>[^/]+)"),
+			},
 		}, {
 			Namespace: "AWS/DynamoDB",
 			Alias:     "dynamodb",
@@ -297,6 +535,24 @@ var (
 			DimensionRegexps: []*string{
 				aws.String("file-system/(?P<FileSystemId>[^/]+)"),
 			},
+		}, {
+			Namespace: "AWS/ElasticBeanstalk",
+			Alias:     "elasticbeanstalk",
+			ResourceFilters: []*string{
+				aws.String("elasticbeanstalk:environment"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":environment/[^/]+/(?P<EnvironmentName>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/ElasticInference",
+			Alias:     "elastic-inference",
+			ResourceFilters: []*string{
+				aws.String("elastic-inference"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":elastic-inference-accelerator/(?P<ElasticInferenceAcceleratorId>[^/]+)$"),
+			},
 		}, {
 			Namespace: "AWS/ELB",
 			Alias:     "elb",
@@ -324,6 +580,17 @@ var (
 			DimensionRegexps: []*string{
 				aws.String(":domain/(?P<DomainName>[^/]+)"),
 			},
+		}, {
+			Namespace: "AWS/Events",
+			Alias:     "eventbridge",
+			ResourceFilters: []*string{
+				aws.String("events"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":rule/(?P<EventBusName>[^/]+)/(?P<RuleName>[^/]+)$"),
+				aws.String(":rule/(?P<RuleName>[^/]+)$"),
+				aws.String(":event-bus/(?P<EventBusName>[^/]+)$"),
+			},
 		}, {
 			Namespace: "AWS/Firehose",
 			Alias:     "firehose",
@@ -360,6 +627,19 @@ var (
 			DimensionRegexps: []*string{
 				aws.String(":job/(?P<JobName>[^/]+)"),
 			},
+		}, {
+			Namespace:    "AWS/GlobalAccelerator",
+			Alias:        "ga",
+			PinnedRegion: "us-west-2",
+			ResourceFilters: []*string{
+				aws.String("globalaccelerator"),
+			},
+			DimensionRegexps: []*string{
+				aws.String("accelerator/(?P<Accelerator>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/GroundStation",
+			Alias:     "groundstation",
 		}, {
 			Namespace: "AWS/IoT",
 			Alias:     "iot",
@@ -371,6 +651,15 @@ var (
 				aws.String(":rule/(?P<RuleName>[^/]+)"),
 				aws.String(":provisioningtemplate/(?P<TemplateName>[^/]+)"),
 			},
+		}, {
+			Namespace: "AWS/IVS",
+			Alias:     "ivs",
+			ResourceFilters: []*string{
+				aws.String("ivs:channel"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":channel/(?P<Channel>[^/]+)$"),
+			},
 		}, {
 			Namespace: "AWS/Kafka",
 			Alias:     "kafka",
@@ -380,6 +669,15 @@ var (
 			DimensionRegexps: []*string{
 				aws.String(":cluster/(?P<Cluster_Name>[^/]+)"),
 			},
+		}, {
+			Namespace: "AWS/Kendra",
+			Alias:     "kendra",
+			ResourceFilters: []*string{
+				aws.String("kendra:index"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":index/(?P<IndexId>[^/]+)$"),
+			},
 		}, {
 			Namespace: "AWS/Kinesis",
 			Alias:     "kinesis",
@@ -389,6 +687,24 @@ var (
 			DimensionRegexps: []*string{
 				aws.String(":stream/(?P<StreamName>[^/]+)"),
 			},
+		}, {
+			Namespace: "AWS/KinesisAnalytics",
+			Alias:     "kinesis-analytics",
+			ResourceFilters: []*string{
+				aws.String("kinesisanalytics:application"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":application/(?P<Application>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/KinesisVideo",
+			Alias:     "kinesisvideo",
+			ResourceFilters: []*string{
+				aws.String("kinesisvideo:stream"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":stream/(?P<StreamName>[^/]+)/"),
+			},
 		}, {
 			Namespace: "AWS/Lambda",
 			Alias:     "lambda",
@@ -398,6 +714,109 @@ var (
 			DimensionRegexps: []*string{
 				aws.String(":function:(?P<FunctionName>[^/]+)"),
 			},
+		}, {
+			Namespace: "AWS/Lex",
+			Alias:     "lex",
+			ResourceFilters: []*string{
+				aws.String("lex:bot"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":bot:(?P<BotName>[^:]+)$"),
+			},
+		}, {
+			Namespace: "AWS/Lightsail",
+			Alias:     "lightsail",
+		}, {
+			Namespace: "AWS/Logs",
+			Alias:     "logs",
+			ResourceFilters: []*string{
+				aws.String("logs:log-group"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":log-group:(?P<LogGroupName>.+)$"),
+			},
+		}, {
+			Namespace: "AWS/MediaConvert",
+			Alias:     "mediaconvert",
+			ResourceFilters: []*string{
+				aws.String("mediaconvert"),
+			},
+			DimensionRegexps: []*string{
+				aws.String("(?P<Queue>.*:.*:mediaconvert:.*:queues/.*)$"),
+			},
+		}, {
+			Namespace: "AWS/MediaLive",
+			Alias:     "medialive",
+			ResourceFilters: []*string{
+				aws.String("medialive:channel"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":channel:(?P<ChannelId>.+)$"),
+			},
+		}, {
+			Namespace: "AWS/MediaPackage",
+			Alias:     "mediapackage",
+			ResourceFilters: []*string{
+				aws.String("mediapackage"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":origin_endpoints/[^/]+/(?P<Channel>[^/]+)/(?P<OriginEndpoint>[^/]+)$"),
+				aws.String(":channels/[^/]+/(?P<Channel>[^/]+)$"),
+			},
+			FilterFunc: func(iface tagsInterface, inputResources []*tagsData) (outputResources []*tagsData, err error) {
+				ctx := context.Background()
+				// The ARNs of channels and origin endpoints contain a generated resource id,
+				// so the ids used as metric dimensions are appended to the ARNs
+				ids := make(map[string]string)
+				err = iface.mediaPackageClient.ListChannelsPagesWithContext(ctx, &mediapackage.ListChannelsInput{},
+					func(page *mediapackage.ListChannelsOutput, lastPage bool) bool {
+						mediaPackageAPICounter.Inc()
+						for _, channel := range page.Channels {
+							ids[*channel.Arn] = *channel.Id
+						}
+						return true
+					})
+				if err != nil {
+					return nil, err
+				}
+				err = iface.mediaPackageClient.ListOriginEndpointsPagesWithContext(ctx, &mediapackage.ListOriginEndpointsInput{},
+					func(page *mediapackage.ListOriginEndpointsOutput, lastPage bool) bool {
+						mediaPackageAPICounter.Inc()
+						for _, endpoint := range page.OriginEndpoints {
+							ids[*endpoint.Arn] = fmt.Sprintf("%s/%s", *endpoint.ChannelId, *endpoint.Id)
+						}
+						return true
+					})
+				if err != nil {
+					return nil, err
+				}
+				for _, resource := range inputResources {
+					r := resource
+					if id, ok := ids[*resource.ID]; ok {
+						r.ID = aws.String(fmt.Sprintf("%s/%s", *resource.ID, id))
+					}
+					outputResources = append(outputResources, r)
+				}
+				return outputResources, nil
+			},
+		}, {
+			Namespace: "AWS/MediaTailor",
+			Alias:     "mediatailor",
+			ResourceFilters: []*string{
+				aws.String("mediatailor:playbackConfiguration"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":playbackConfiguration/(?P<ConfigurationName>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/MemoryDB",
+			Alias:     "memorydb",
+			ResourceFilters: []*string{
+				aws.String("memorydb:cluster"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":cluster/(?P<ClusterName>[^/]+)$"),
+			},
 		}, {
 			Namespace: "AWS/Neptune",
 			Alias:     "neptune",
@@ -438,6 +857,64 @@ var (
 				aws.String(":(?P<TargetGroup>targetgroup/.+)"),
 				aws.String(":loadbalancer/(?P<LoadBalancer>.+)$"),
 			},
+			DescribeFunc: describeLoadBalancers("network"),
+		}, {
+			Namespace: "AWS/Outposts",
+			Alias:     "outposts",
+			ResourceFilters: []*string{
+				aws.String("outposts"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":outpost/(?P<OutpostId>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/Personalize",
+			Alias:     "personalize",
+			ResourceFilters: []*string{
+				aws.String("personalize:campaign"),
+			},
+			DimensionRegexps: []*string{
+				aws.String("(?P<CampaignArn>.*:campaign/[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/Polly",
+			Alias:     "polly",
+		}, {
+			Namespace: "AWS/PrivateLinkEndpoints",
+			Alias:     "vpc-endpoint",
+			ResourceFilters: []*string{
+				aws.String("ec2:vpc-endpoint"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":vpc-endpoint/(?P<VPC_Endpoint_Id>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/PrivateLinkServices",
+			Alias:     "vpc-endpoint-service",
+			ResourceFilters: []*string{
+				aws.String("ec2:vpc-endpoint-service"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":vpc-endpoint-service/(?P<Service_Id>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/Prometheus",
+			Alias:     "amp",
+			ResourceFilters: []*string{
+				aws.String("aps"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":workspace/(?P<WorkspaceId>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/QLDB",
+			Alias:     "qldb",
+			ResourceFilters: []*string{
+				aws.String("qldb"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":ledger/(?P<LedgerName>[^/]+)$"),
+			},
 		}, {
 			Namespace: "AWS/RDS",
 			Alias:     "rds",
@@ -449,6 +926,7 @@ var (
 				aws.String(":cluster:(?P<DBClusterIdentifier>[^/]+)"),
 				aws.String(":db:(?P<DBInstanceIdentifier>[^/]+)"),
 			},
+			DescribeFunc: describeDBInstancesAndClusters,
 		}, {
 			Namespace: "AWS/Redshift",
 			Alias:     "redshift",
@@ -458,6 +936,41 @@ var (
 			DimensionRegexps: []*string{
 				aws.String(":cluster:(?P<ClusterIdentifier>[^/]+)"),
 			},
+		}, {
+			Namespace: "AWS/Redshift-Serverless",
+			Alias:     "redshift-serverless",
+		}, {
+			Namespace: "AWS/Rekognition",
+			Alias:     "rekognition",
+		}, {
+			Namespace: "AWS/RoboMaker",
+			Alias:     "robomaker",
+			ResourceFilters: []*string{
+				aws.String("robomaker:simulation-job"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":simulation-job/(?P<SimulationJobId>[^/]+)$"),
+			},
+		}, {
+			Namespace:    "AWS/Route53",
+			Alias:        "route53",
+			PinnedRegion: "us-east-1",
+			ResourceFilters: []*string{
+				aws.String("route53:hostedzone"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":hostedzone/(?P<HostedZoneId>[^/]+)$"),
+			},
+		}, {
+			Namespace:    "AWS/Route53",
+			Alias:        "route53-healthcheck",
+			PinnedRegion: "us-east-1",
+			ResourceFilters: []*string{
+				aws.String("route53:healthcheck"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":healthcheck/(?P<HealthCheckId>[^/]+)$"),
+			},
 		}, {
 			Namespace: "AWS/Route53Resolver",
 			Alias:     "r53r",
@@ -477,6 +990,15 @@ var (
 			DimensionRegexps: []*string{
 				aws.String("(?P<BucketName>[^:]+)$"),
 			},
+		}, {
+			Namespace: "AWS/SageMaker",
+			Alias:     "sagemaker",
+			ResourceFilters: []*string{
+				aws.String("sagemaker:endpoint"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":endpoint/(?P<EndpointName>[^/]+)$"),
+			},
 		}, {
 			Namespace: "AWS/SES",
 			Alias:     "ses",
@@ -507,6 +1029,39 @@ var (
 			DimensionRegexps: []*string{
 				aws.String("(?P<QueueName>[^:]+)$"),
 			},
+		}, {
+			Namespace: "AWS/StorageGateway",
+			Alias:     "storagegateway",
+			ResourceFilters: []*string{
+				aws.String("storagegateway"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":gateway/(?P<GatewayId>[^/]+)/volume/(?P<VolumeId>[^/]+)$"),
+				aws.String(":gateway/(?P<GatewayId>[^/]+)$"),
+				aws.String(":share/(?P<ShareId>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/SWF",
+			Alias:     "swf",
+		}, {
+			Namespace: "AWS/Timestream",
+			Alias:     "timestream",
+			ResourceFilters: []*string{
+				aws.String("timestream:database"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":database/(?P<DatabaseName>[^/]+)/table/(?P<TableName>[^/]+)$"),
+				aws.String(":database/(?P<DatabaseName>[^/]+)$"),
+			},
+		}, {
+			Namespace: "AWS/Transfer",
+			Alias:     "transfer",
+			ResourceFilters: []*string{
+				aws.String("transfer:server"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":server/(?P<ServerId>[^/]+)$"),
+			},
 		}, {
 			Namespace: "AWS/TransitGateway",
 			Alias:     "tgw",
@@ -544,6 +1099,16 @@ var (
 					},
 				)
 			},
+		}, {
+			Namespace: "AWS/Translate",
+			Alias:     "translate",
+		}, {
+			Namespace:    "AWS/TrustedAdvisor",
+			Alias:        "trustedadvisor",
+			PinnedRegion: "us-east-1",
+		}, {
+			Namespace: "AWS/Usage",
+			Alias:     "usage",
 		}, {
 			Namespace: "AWS/VPN",
 			Alias:     "vpn",
@@ -553,6 +1118,10 @@ var (
 			DimensionRegexps: []*string{
 				aws.String(":vpn-connection/(?P<VpnId>[^/]+)"),
 			},
+		}, {
+			// WAF Classic web ACLs of CloudFront report in us-east-1, regional web ACLs in their region
+			Namespace: "AWS/WAF",
+			Alias:     "waf",
 		}, {
 			Namespace: "AWS/WAFV2",
 			Alias:     "wafv2",
@@ -573,6 +1142,15 @@ var (
 				aws.String(":workspace/(?P<WorkspaceId>[^/]+)$"),
 				aws.String(":directory/(?P<DirectoryId>[^/]+)$"),
 			},
+		}, {
+			Namespace: "LambdaInsights",
+			Alias:     "lambda-insights",
+			ResourceFilters: []*string{
+				aws.String("lambda:function"),
+			},
+			DimensionRegexps: []*string{
+				aws.String(":function:(?P<function__name>[^/]+)"),
+			},
 		},
 	}
 )
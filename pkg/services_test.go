@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestWAFClassic(t *testing.T) {
+	// CloudFront and regional web ACLs share the namespace, they are scraped by one type from the regions of the job
+	svc := SupportedServices.GetService("AWS/WAF")
+	equals(t, "waf", svc.Alias)
+	equals(t, "", svc.PinnedRegion)
+	equals(t, []string{"waf"}, SupportedServices.namespaceAliases("AWS/WAF"))
+
+	job := &Job{
+		Type:    "AWS/WAF",
+		Regions: []string{"us-east-1", "eu-west-1"},
+		Roles:   []Role{{}},
+		Metrics: []*Metric{{Name: "BlockedRequests", Statistics: []string{"Sum"}}},
+	}
+	if err := job.validateDiscoveryJob(0); err != nil {
+		t.Fatal(err)
+	}
+	equals(t, []string{"us-east-1", "eu-west-1"}, job.Regions)
+}
+
+func TestDimensionRegexps(t *testing.T) {
+	tests := []struct {
+		service    string
+		arn        string
+		dimensions map[string]string
+	}{
+		{"acm", "arn:aws:acm:eu-west-1:123456789012:certificate/12345678-1234-1234-1234-123456789012", map[string]string{"CertificateArn": "arn:aws:acm:eu-west-1:123456789012:certificate/12345678-1234-1234-1234-123456789012"}},
+		{"amp", "arn:aws:aps:eu-west-1:123456789012:workspace/ws-12345678-1234-1234-1234-123456789012", map[string]string{"WorkspaceId": "ws-12345678-1234-1234-1234-123456789012"}},
+		{"amplify", "arn:aws:amplify:eu-west-1:123456789012:apps/d2abcdefghijkl", map[string]string{"App": "d2abcdefghijkl"}},
+		{"appflow", "arn:aws:appflow:eu-west-1:123456789012:flow/salesforce-export", map[string]string{"FlowName": "salesforce-export"}},
+		{"apprunner", "arn:aws:apprunner:eu-west-1:123456789012:service/web/8fe1e10304f84fd2b0df550fe98a71fa", map[string]string{"ServiceName": "web", "ServiceID": "8fe1e10304f84fd2b0df550fe98a71fa"}},
+		{"appstream", "arn:aws:appstream:eu-west-1:123456789012:fleet/office", map[string]string{"Fleet": "office"}},
+		{"athena", "arn:aws:athena:eu-west-1:123456789012:workgroup/primary", map[string]string{"WorkGroup": "primary"}},
+		{"backup", "arn:aws:backup:eu-west-1:123456789012:backup-vault:Default", map[string]string{"BackupVaultName": "Default"}},
+		{"batch", "arn:aws:batch:eu-west-1:123456789012:job-queue/high-priority", map[string]string{"JobQueue": "high-priority"}},
+		{"cassandra", "arn:aws:cassandra:eu-west-1:123456789012:/keyspace/shop/table/orders", map[string]string{"Keyspace": "shop", "TableName": "orders"}},
+		{"clientvpn", "arn:aws:ec2:eu-west-1:123456789012:client-vpn-endpoint/cvpn-endpoint-0123456789abcdef0", map[string]string{"Endpoint": "cvpn-endpoint-0123456789abcdef0"}},
+		{"cloudhsm", "arn:aws:cloudhsm:eu-west-1:123456789012:cluster/cluster-abcdefghijk", map[string]string{"ClusterId": "cluster-abcdefghijk"}},
+		{"codebuild", "arn:aws:codebuild:eu-west-1:123456789012:project/backend", map[string]string{"ProjectName": "backend"}},
+		{"connect", "arn:aws:connect:eu-west-1:123456789012:instance/12345678-1234-1234-1234-123456789012", map[string]string{"InstanceId": "12345678-1234-1234-1234-123456789012"}},
+		{"datasync", "arn:aws:datasync:eu-west-1:123456789012:task/task-0123456789abcdef0", map[string]string{"TaskId": "task-0123456789abcdef0"}},
+		{"datasync", "arn:aws:datasync:eu-west-1:123456789012:agent/agent-0123456789abcdef0", map[string]string{"AgentId": "agent-0123456789abcdef0"}},
+		{"dax", "arn:aws:dax:eu-west-1:123456789012:cache/sessions", map[string]string{"ClusterId": "sessions"}},
+		{"dms", "arn:aws:dms:eu-west-1:123456789012:task:ABCDEFGHIJKLMNOPQRSTUVWXYZ/my-instance", map[string]string{"ReplicationTaskIdentifier": "ABCDEFGHIJKLMNOPQRSTUVWXYZ", "ReplicationInstanceIdentifier": "my-instance"}},
+		{"dms", "arn:aws:dms:eu-west-1:123456789012:rep:ABCDEFGHIJKLMNOPQRSTUVWXYZ/my-instance", map[string]string{"ReplicationInstanceIdentifier": "my-instance"}},
+		{"dx", "arn:aws:directconnect:eu-west-1:123456789012:dxcon/dxcon-abcd1234", map[string]string{"ConnectionId": "dxcon-abcd1234"}},
+		{"dx", "arn:aws:directconnect:eu-west-1:123456789012:dxvif/dxvif-abcd1234", map[string]string{"VirtualInterfaceId": "dxvif-abcd1234"}},
+		{"elastic-inference", "arn:aws:elastic-inference:eu-west-1:123456789012:elastic-inference-accelerator/eia-0123456789abcdef0", map[string]string{"ElasticInferenceAcceleratorId": "eia-0123456789abcdef0"}},
+		{"elasticbeanstalk", "arn:aws:elasticbeanstalk:eu-west-1:123456789012:environment/shop/shop-production", map[string]string{"EnvironmentName": "shop-production"}},
+		{"eventbridge", "arn:aws:events:eu-west-1:123456789012:rule/orders/order-created", map[string]string{"EventBusName": "orders", "RuleName": "order-created"}},
+		{"eventbridge", "arn:aws:events:eu-west-1:123456789012:rule/nightly", map[string]string{"RuleName": "nightly"}},
+		{"eventbridge", "arn:aws:events:eu-west-1:123456789012:event-bus/orders", map[string]string{"EventBusName": "orders"}},
+		{"ga", "arn:aws:globalaccelerator::123456789012:accelerator/12345678-1234-1234-1234-123456789012", map[string]string{"Accelerator": "12345678-1234-1234-1234-123456789012"}},
+		{"ivs", "arn:aws:ivs:eu-west-1:123456789012:channel/AbCdEfGhIjKl", map[string]string{"Channel": "AbCdEfGhIjKl"}},
+		{"kendra", "arn:aws:kendra:eu-west-1:123456789012:index/12345678-1234-1234-1234-123456789012", map[string]string{"IndexId": "12345678-1234-1234-1234-123456789012"}},
+		{"kinesis-analytics", "arn:aws:kinesisanalytics:eu-west-1:123456789012:application/clickstream", map[string]string{"Application": "clickstream"}},
+		{"kinesisvideo", "arn:aws:kinesisvideo:eu-west-1:123456789012:stream/doorbell/1234567890123", map[string]string{"StreamName": "doorbell"}},
+		{"lambda-insights", "arn:aws:lambda:eu-west-1:123456789012:function:checkout", map[string]string{"function_name": "checkout"}},
+		{"lex", "arn:aws:lex:eu-west-1:123456789012:bot:OrderFlowers", map[string]string{"BotName": "OrderFlowers"}},
+		{"logs", "arn:aws:logs:eu-west-1:123456789012:log-group:/aws/lambda/checkout", map[string]string{"LogGroupName": "/aws/lambda/checkout"}},
+		{"mediaconvert", "arn:aws:mediaconvert:eu-west-1:123456789012:queues/Default", map[string]string{"Queue": "arn:aws:mediaconvert:eu-west-1:123456789012:queues/Default"}},
+		{"medialive", "arn:aws:medialive:eu-west-1:123456789012:channel:1234567", map[string]string{"ChannelId": "1234567"}},
+		{"mediapackage", "arn:aws:mediapackage:eu-west-1:123456789012:origin_endpoints/1234567890abcdef1234567890abcdef/live-channel/live-hls", map[string]string{"Channel": "live-channel", "OriginEndpoint": "live-hls"}},
+		{"mediapackage", "arn:aws:mediapackage:eu-west-1:123456789012:channels/1234567890abcdef1234567890abcdef/live-channel", map[string]string{"Channel": "live-channel"}},
+		{"mediatailor", "arn:aws:mediatailor:eu-west-1:123456789012:playbackConfiguration/ads", map[string]string{"ConfigurationName": "ads"}},
+		{"memorydb", "arn:aws:memorydb:eu-west-1:123456789012:cluster/sessions", map[string]string{"ClusterName": "sessions"}},
+		{"mq", "arn:aws:mq:eu-west-1:123456789012:broker:orders:b-12345678-1234-1234-1234-123456789012", map[string]string{"Broker": "orders"}},
+		{"mwaa", "arn:aws:airflow:eu-west-1:123456789012:environment/etl", map[string]string{"Environment": "etl"}},
+		{"outposts", "arn:aws:outposts:eu-west-1:123456789012:outpost/op-0123456789abcdef0", map[string]string{"OutpostId": "op-0123456789abcdef0"}},
+		{"personalize", "arn:aws:personalize:eu-west-1:123456789012:campaign/recommendations", map[string]string{"CampaignArn": "arn:aws:personalize:eu-west-1:123456789012:campaign/recommendations"}},
+		{"qldb", "arn:aws:qldb:eu-west-1:123456789012:ledger/payments", map[string]string{"LedgerName": "payments"}},
+		{"robomaker", "arn:aws:robomaker:eu-west-1:123456789012:simulation-job/sim-0123456789ab", map[string]string{"SimulationJobId": "sim-0123456789ab"}},
+		{"route53", "arn:aws:route53:::hostedzone/Z0123456789ABCDEFGHIJ", map[string]string{"HostedZoneId": "Z0123456789ABCDEFGHIJ"}},
+		{"route53-healthcheck", "arn:aws:route53:::healthcheck/12345678-1234-1234-1234-123456789012", map[string]string{"HealthCheckId": "12345678-1234-1234-1234-123456789012"}},
+		{"sagemaker", "arn:aws:sagemaker:eu-west-1:123456789012:endpoint/recommender", map[string]string{"EndpointName": "recommender"}},
+		{"shield", "arn:aws:cloudfront::123456789012:distribution/E1ABCDEFGHIJKL", map[string]string{"ResourceArn": "arn:aws:cloudfront::123456789012:distribution/E1ABCDEFGHIJKL"}},
+		{"storagegateway", "arn:aws:storagegateway:eu-west-1:123456789012:gateway/sgw-12A3456B/volume/vol-1122AABB", map[string]string{"GatewayId": "sgw-12A3456B", "VolumeId": "vol-1122AABB"}},
+		{"storagegateway", "arn:aws:storagegateway:eu-west-1:123456789012:gateway/sgw-12A3456B", map[string]string{"GatewayId": "sgw-12A3456B"}},
+		{"storagegateway", "arn:aws:storagegateway:eu-west-1:123456789012:share/share-ABCDEF12", map[string]string{"ShareId": "share-ABCDEF12"}},
+		{"timestream", "arn:aws:timestream:eu-west-1:123456789012:database/metrics/table/cpu", map[string]string{"DatabaseName": "metrics", "TableName": "cpu"}},
+		{"timestream", "arn:aws:timestream:eu-west-1:123456789012:database/metrics", map[string]string{"DatabaseName": "metrics"}},
+		{"transfer", "arn:aws:transfer:eu-west-1:123456789012:server/s-0123456789abcdef0", map[string]string{"ServerId": "s-0123456789abcdef0"}},
+		{"vpc-endpoint", "arn:aws:ec2:eu-west-1:123456789012:vpc-endpoint/vpce-0123456789abcdef0", map[string]string{"VPC Endpoint Id": "vpce-0123456789abcdef0"}},
+		{"vpc-endpoint-service", "arn:aws:ec2:eu-west-1:123456789012:vpc-endpoint-service/vpce-svc-0123456789abcdef0", map[string]string{"Service Id": "vpce-svc-0123456789abcdef0"}},
+	}
+
+	for _, test := range tests {
+		svc := SupportedServices.GetService(test.service)
+		if svc == nil {
+			t.Fatalf("%s is not a supported service", test.service)
+		}
+		dimensions := make(map[string]string)
+		for _, dimension := range resourceDimensions(svc.DimensionRegexps, &tagsData{ID: aws.String(test.arn)}) {
+			dimensions[*dimension.Name] = *dimension.Value
+		}
+		if !reflect.DeepEqual(test.dimensions, dimensions) {
+			t.Errorf("%s: expected dimensions %v of %s, got %v", test.service, test.dimensions, test.arn, dimensions)
+		}
+	}
+}
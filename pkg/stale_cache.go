@@ -0,0 +1,124 @@
+package exporter
+
+import (
+	"sync"
+	"time"
+)
+
+// staleData keeps the data of the last successful scrape of every job, role and region, to serve it when a scrape fails
+var staleData = newStaleCache()
+
+type staleCacheKey struct {
+	name   string
+	role   string
+	region string
+}
+
+type staleCacheEntry struct {
+	time      time.Time
+	resources []*tagsData
+	metrics   []*cloudwatchData
+}
+
+type staleCache struct {
+	mux     sync.Mutex
+	entries map[staleCacheKey]staleCacheEntry
+	ages    map[staleCacheKey]float64
+}
+
+func newStaleCache() *staleCache {
+	return &staleCache{
+		entries: make(map[staleCacheKey]staleCacheEntry),
+		ages:    make(map[staleCacheKey]float64),
+	}
+}
+
+func (c *staleCache) startScrape() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.ages = make(map[staleCacheKey]float64)
+}
+
+// use caches the data of a successful scrape, and returns the cached data instead of the data of a failed scrape
+// as long as it's at most maxStale seconds old
+func (c *staleCache) use(key staleCacheKey, maxStale int, failed bool, now time.Time, resources []*tagsData, metrics []*cloudwatchData) ([]*tagsData, []*cloudwatchData) {
+	if maxStale <= 0 {
+		return resources, metrics
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if !failed {
+		c.entries[key] = staleCacheEntry{time: now, resources: resources, metrics: metrics}
+		c.ages[key] = 0
+		return resources, metrics
+	}
+	entry, ok := c.entries[key]
+	if !ok {
+		return resources, metrics
+	}
+	age := now.Sub(entry.time)
+	if age > time.Duration(maxStale)*time.Second {
+		delete(c.entries, key)
+		return resources, metrics
+	}
+	c.ages[key] = age.Seconds()
+	return entry.resources, entry.metrics
+}
+
+// useDiscoveryJobs applies use to the data of every job type scraped together in a role and region, failed has the job
+// types whose scrape failed
+func (c *staleCache) useDiscoveryJobs(jobs []*Job, role Role, region string, failed map[string]bool, now time.Time, resources []*tagsData, metrics []*cloudwatchData) ([]*tagsData, []*cloudwatchData) {
+	var usedResources []*tagsData
+	var usedMetrics []*cloudwatchData
+	seen := make(map[string]bool)
+	for _, job := range jobs {
+		if seen[job.Type] {
+			continue
+		}
+		seen[job.Type] = true
+
+		maxStale := 0
+		for _, j := range jobs {
+			if j.Type == job.Type && j.MaxStale > maxStale {
+				maxStale = j.MaxStale
+			}
+		}
+
+		var jobResources []*tagsData
+		for _, resource := range resources {
+			if *resource.Namespace == job.Type {
+				jobResources = append(jobResources, resource)
+			}
+		}
+		var jobMetrics []*cloudwatchData
+		for _, metric := range metrics {
+			if *metric.Namespace == job.Type {
+				jobMetrics = append(jobMetrics, metric)
+			}
+		}
+
+		key := staleCacheKey{name: job.Type, role: role.key(), region: region}
+		jobResources, jobMetrics = c.use(key, maxStale, failed[job.Type], now, jobResources, jobMetrics)
+		usedResources = append(usedResources, jobResources...)
+		usedMetrics = append(usedMetrics, jobMetrics...)
+	}
+	return usedResources, usedMetrics
+}
+
+// publish sets the data age gauge of every job served from the cache in the current scrape, the oldest data of
+// all roles counts
+func (c *staleCache) publish() {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	dataAgeGauge.Reset()
+	ages := make(map[[2]string]float64)
+	for key, age := range c.ages {
+		labels := [2]string{key.name, key.region}
+		if current, ok := ages[labels]; !ok || age > current {
+			ages[labels] = age
+		}
+	}
+	for labels, age := range ages {
+		dataAgeGauge.WithLabelValues(labels[0], labels[1]).Set(age)
+	}
+}
@@ -0,0 +1,55 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestStaleCache(t *testing.T) {
+	cache := newStaleCache()
+	now := time.Now()
+	key := staleCacheKey{name: "ec2", region: "eu-west-1"}
+	metrics := []*cloudwatchData{{Namespace: aws.String("ec2")}}
+
+	cache.startScrape()
+	_, used := cache.use(key, 600, false, now, nil, metrics)
+	equals(t, metrics, used)
+
+	// A failed scrape serves the cached data while it's at most maxStale seconds old
+	cache.startScrape()
+	_, used = cache.use(key, 600, true, now.Add(5*time.Minute), nil, nil)
+	equals(t, metrics, used)
+	cache.publish()
+	equals(t, float64(300), testutil.ToFloat64(dataAgeGauge.WithLabelValues("ec2", "eu-west-1")))
+
+	cache.startScrape()
+	_, used = cache.use(key, 600, true, now.Add(11*time.Minute), nil, nil)
+	equals(t, 0, len(used))
+
+	// Without maxStale nothing is cached
+	cache.startScrape()
+	cache.use(key, 0, false, now, nil, metrics)
+	_, used = cache.use(key, 600, true, now, nil, nil)
+	equals(t, 0, len(used))
+}
+
+func TestStaleCacheDiscoveryJobs(t *testing.T) {
+	cache := newStaleCache()
+	now := time.Now()
+	jobs := []*Job{{Type: "ec2", MaxStale: 600}, {Type: "s3", MaxStale: 600}}
+	ec2 := &cloudwatchData{Namespace: aws.String("ec2")}
+	s3 := &cloudwatchData{Namespace: aws.String("s3")}
+
+	_, used := cache.useDiscoveryJobs(jobs, Role{}, "eu-west-1", nil, now, nil, []*cloudwatchData{ec2, s3})
+	equals(t, 2, len(used))
+
+	// Only the failed job is served from the cache, its empty data doesn't replace the cached data
+	s3Fresh := &cloudwatchData{Namespace: aws.String("s3")}
+	_, used = cache.useDiscoveryJobs(jobs, Role{}, "eu-west-1", map[string]bool{"ec2": true}, now.Add(time.Minute), nil, []*cloudwatchData{s3Fresh})
+	equals(t, []*cloudwatchData{ec2, s3Fresh}, used)
+	_, used = cache.useDiscoveryJobs(jobs, Role{}, "eu-west-1", map[string]bool{"ec2": true, "s3": true}, now.Add(2*time.Minute), nil, nil)
+	equals(t, []*cloudwatchData{ec2, s3Fresh}, used)
+}
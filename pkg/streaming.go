@@ -0,0 +1,104 @@
+package exporter
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	log "github.com/sirupsen/logrus"
+)
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// StreamingHandler serves the metrics of the collector in the text exposition format. Metrics are encoded and
+// written one by one instead of gathering the whole response in memory first.
+func StreamingHandler(collector *PrometheusCollector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", string(expfmt.FmtText))
+
+		var out io.Writer = w
+		if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			defer gz.Close()
+			out = gz
+		}
+
+		if err := collector.writeText(out); err != nil {
+			log.Warningf("Couldn't write metrics: %v", err)
+			return
+		}
+
+		// The API counters are few, so they are gathered the usual way
+		registry := prometheus.NewRegistry()
+		registerAPICounters(registry)
+		families, err := registry.Gather()
+		if err != nil {
+			log.Warningf("Couldn't gather api metrics: %v", err)
+			return
+		}
+		for _, family := range families {
+			if _, err := expfmt.MetricFamilyToText(out, family); err != nil {
+				log.Warningf("Couldn't write api metrics: %v", err)
+				return
+			}
+		}
+	})
+}
+
+// writeText writes the metrics in the text exposition format, the metrics are sorted by name to write each family at once.
+func (p *PrometheusCollector) writeText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	var previousName string
+	for _, metric := range p.metrics {
+		if *metric.name != previousName {
+			bw.WriteString("# HELP " + *metric.name + " Help is not implemented yet.\n")
+			bw.WriteString("# TYPE " + *metric.name + " gauge\n")
+			previousName = *metric.name
+		}
+
+		bw.WriteString(*metric.name)
+		if len(metric.labelNames) > 0 {
+			bw.WriteByte('{')
+			for i, name := range metric.labelNames {
+				if i > 0 {
+					bw.WriteByte(',')
+				}
+				bw.WriteString(name)
+				bw.WriteString(`="`)
+				labelValueReplacer.WriteString(bw, metric.labelValues[i])
+				bw.WriteByte('"')
+			}
+			bw.WriteByte('}')
+		}
+		bw.WriteByte(' ')
+		bw.WriteString(formatValue(*metric.value))
+		if metric.includeTimestamp {
+			bw.WriteByte(' ')
+			bw.WriteString(strconv.FormatInt(metric.timestamp.UnixNano()/1e6, 10))
+		}
+		if _, err := bw.WriteString("\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+func TestWriteTextMatchesExpfmt(t *testing.T) {
+	requests := "aws_elb_request_count_sum"
+	latency := "aws_elb_latency_average"
+	one := float64(1)
+	nan := math.NaN()
+	small := 0.000123
+	metrics := []*PrometheusMetric{
+		{
+			name:        &requests,
+			labelNames:  []string{"name", "region"},
+			labelValues: []string{"lb-1", "eu-west-1"},
+			value:       &one,
+		},
+		{
+			name:        &latency,
+			labelNames:  []string{"name", "region"},
+			labelValues: []string{"lb-\"quoted\"\nnewline\\", "eu-west-1"},
+			value:       &nan,
+		},
+		{
+			name:             &requests,
+			labelNames:       []string{"name", "region"},
+			labelValues:      []string{"lb-2", "eu-west-1"},
+			value:            &small,
+			includeTimestamp: true,
+			timestamp:        time.Unix(1600000000, 0),
+		},
+	}
+	collector := NewPrometheusCollector(metrics)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected bytes.Buffer
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(&expected, family); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var actual bytes.Buffer
+	if err := collector.writeText(&actual); err != nil {
+		t.Fatal(err)
+	}
+
+	equals(t, expected.String(), actual.String())
+}
@@ -7,8 +7,16 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func UpdateMetrics(config ScrapeConf, registry *prometheus.Registry, now time.Time, metricsPerQuery int, fips, floatingTimeWindow, labelsSnakeCase bool, cloudwatchSemaphore, tagSemaphore chan struct{}) time.Time {
-	tagsData, cloudwatchData, endtime := scrapeAwsData(config, now, metricsPerQuery, fips, floatingTimeWindow, cloudwatchSemaphore, tagSemaphore)
+func UpdateMetrics(config ScrapeConf, registry *prometheus.Registry, now time.Time, metricsPerQuery int, cloudwatchQuotaFraction float64, fips, floatingTimeWindow, labelsSnakeCase bool, cloudwatchSemaphore, tagSemaphore chan struct{}) time.Time {
+	collector, endtime := ScrapeMetrics(config, now, metricsPerQuery, cloudwatchQuotaFraction, fips, floatingTimeWindow, labelsSnakeCase, cloudwatchSemaphore, tagSemaphore)
+	registry.MustRegister(collector)
+	registerAPICounters(registry)
+	return endtime
+}
+
+// ScrapeMetrics scrapes all jobs and returns their metrics without registering them, e.g. for serving them with StreamingHandler
+func ScrapeMetrics(config ScrapeConf, now time.Time, metricsPerQuery int, cloudwatchQuotaFraction float64, fips, floatingTimeWindow, labelsSnakeCase bool, cloudwatchSemaphore, tagSemaphore chan struct{}) (*PrometheusCollector, time.Time) {
+	tagsData, cloudwatchData, endtime := scrapeAwsData(config, now, metricsPerQuery, cloudwatchQuotaFraction, fips, floatingTimeWindow, cloudwatchSemaphore, tagSemaphore)
 	var metrics []*PrometheusMetric
 
 	metrics = append(metrics, migrateCloudwatchToPrometheus(cloudwatchData, labelsSnakeCase)...)
@@ -16,11 +24,32 @@ func UpdateMetrics(config ScrapeConf, registry *prometheus.Registry, now time.Ti
 
 	metrics = append(metrics, migrateTagsToPrometheus(tagsData, labelsSnakeCase)...)
 
-	registry.MustRegister(NewPrometheusCollector(metrics))
-	for _, counter := range []prometheus.Counter{cloudwatchAPICounter, cloudwatchGetMetricDataAPICounter, cloudwatchGetMetricStatisticsAPICounter, resourceGroupTaggingAPICounter, autoScalingAPICounter, apiGatewayAPICounter, targetGroupsAPICounter} {
+	return NewPrometheusCollector(metrics), *endtime
+}
+
+// NewRegistry returns a registry of the collector and the API counters, e.g. for serving them with InfluxHandler
+func NewRegistry(collector *PrometheusCollector) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	registerAPICounters(registry)
+	return registry
+}
+
+// Rediscover makes the next scrape query all series of a discovery job type again, or of all jobs without a type,
+// including the series suppressed for returning no datapoints
+func Rediscover(jobType string) {
+	emptyMetrics.forget(jobType)
+}
+
+func registerAPICounters(registry *prometheus.Registry) {
+	for _, counter := range []prometheus.Counter{cloudwatchAPICounter, cloudwatchGetMetricDataAPICounter, cloudwatchGetMetricStatisticsAPICounter, resourceGroupTaggingAPICounter, autoScalingAPICounter, apiGatewayAPICounter, targetGroupsAPICounter, dmsAPICounter, mediaPackageAPICounter, shieldAPICounter, organizationsAPICounter, serviceQuotasAPICounter, elbv2APICounter, rdsAPICounter} {
+		if err := registry.Register(counter); err != nil {
+			log.Warning("Could not publish cloudwatch api metric")
+		}
+	}
+	for _, counter := range []prometheus.Collector{droppedResourcesCounter, droppedSeriesCounter, suppressedQueriesGauge, estimatedCostGauge, budgetExhaustedGauge, regionUpGauge, dataAgeGauge, clockSkewGauge} {
 		if err := registry.Register(counter); err != nil {
 			log.Warning("Could not publish cloudwatch api metric")
 		}
 	}
-	return *endtime
 }